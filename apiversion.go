@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+)
+
+// apiVersionConfig pins the Salesforce API version per resource group.
+// CONNECTOR_API_VERSION sets all of them; CONNECTOR_API_VERSION_SOBJECTS,
+// _QUERY and _COMMERCE override a single group.
+type apiVersionConfig struct {
+	sobjects string
+	query    string
+	commerce string
+}
+
+// The versions the handlers were originally written against
+var apiVersions = apiVersionConfig{
+	sobjects: "v58.0",
+	query:    "v58.0",
+	commerce: "v62.0",
+}
+
+var apiVersionPattern = regexp.MustCompile(`^v\d+\.\d$`)
+
+func loadAPIVersions() (apiVersionConfig, error) {
+	cfg := apiVersions
+	groups := []struct {
+		env     string
+		version *string
+	}{
+		{"SOBJECTS", &cfg.sobjects},
+		{"QUERY", &cfg.query},
+		{"COMMERCE", &cfg.commerce},
+	}
+	global := os.Getenv("CONNECTOR_API_VERSION")
+	for _, g := range groups {
+		v := os.Getenv("CONNECTOR_API_VERSION_" + g.env)
+		if v == "" {
+			v = global
+		}
+		if v == "" {
+			continue
+		}
+		if !strings.HasPrefix(v, "v") {
+			v = "v" + v
+		}
+		if !apiVersionPattern.MatchString(v) {
+			return cfg, fmt.Errorf("invalid Salesforce API version %q for %s", v, strings.ToLower(g.env))
+		}
+		*g.version = v
+	}
+	return cfg, nil
+}
+
+func (cfg apiVersionConfig) distinct() []string {
+	var versions []string
+	for _, v := range []string{cfg.sobjects, cfg.query, cfg.commerce} {
+		if !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// checkAPIVersions makes sure every tenant profile's org serves the pinned
+// versions. Orgs that only come in through request headers can't be known
+// up front and are not checked. An unreachable org is logged, not fatal, so
+// one tenant's outage doesn't keep the connector from starting.
+func checkAPIVersions(cfg apiVersionConfig, registry *tenantRegistry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	checked := map[string]bool{}
+	for _, profile := range registry.byID {
+		if checked[profile.ShopURL] {
+			continue
+		}
+		checked[profile.ShopURL] = true
+
+		supported, err := salesforce.SupportedVersions(ctx, salesforce.DefaultHTTPClient, profile.ShopURL)
+		if err != nil {
+			log.Printf("Could not check API versions for tenant %q: %v", profile.ID, err)
+			continue
+		}
+		var available []string
+		for _, s := range supported {
+			available = append(available, "v"+s.Version)
+		}
+		for _, v := range cfg.distinct() {
+			if !slices.Contains(available, v) {
+				return fmt.Errorf("tenant %q: org does not support API %s (it serves %s)", profile.ID, v, strings.Join(available, ", "))
+			}
+		}
+	}
+	return nil
+}
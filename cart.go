@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getCart returns a cart's summary together with a page of its items.
+// pageSize, pageParam and sort are passed on to the cart items API.
+func getCart(c *gin.Context) {
+	creds := credential(c)
+	cartID := c.Param("cartId")
+	accountID := c.Query("accountID")
+	client := salesforceClient(c, apiVersions.commerce)
+
+	cart, err := client.GetCart(c.Request.Context(), creds.webstoreId, cartID, accountID)
+	if err != nil {
+		respondError(c, err, "Failed to get cart")
+		return
+	}
+
+	params := url.Values{}
+	for _, key := range []string{"pageSize", "pageParam", "sort"} {
+		if value := c.Query(key); value != "" {
+			params.Set(key, value)
+		}
+	}
+	items, err := client.GetCartItems(c.Request.Context(), creds.webstoreId, cartID, accountID, params)
+	if err != nil {
+		respondError(c, err, "Failed to get cart items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cart":  cart,
+		"items": items,
+	})
+}
+
+// updateCartItem sets the quantity of an item, from a body like {"quantity": 2}.
+func updateCartItem(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	var requestBody struct {
+		Quantity json.Number `json:"quantity"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid JSON"))
+		return
+	}
+	quantity, err := strconv.Atoi(requestBody.Quantity.String())
+	if err != nil || quantity < 1 {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "quantity must be a whole number of at least 1; remove the item instead of setting 0"))
+		return
+	}
+
+	// Connect Commerce takes the quantity as a string
+	item := map[string]interface{}{"quantity": strconv.Itoa(quantity)}
+	result, err := salesforceClient(c, apiVersions.commerce).UpdateCartItem(c.Request.Context(), creds.webstoreId, c.Param("cartId"), c.Param("itemId"), accountID, item)
+	if err != nil {
+		respondError(c, err, "Failed to update cart item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Cart item updated successfully",
+		"cartItem": result,
+	})
+}
+
+func removeCartItem(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	if err := salesforceClient(c, apiVersions.commerce).DeleteCartItem(c.Request.Context(), creds.webstoreId, c.Param("cartId"), c.Param("itemId"), accountID); err != nil {
+		respondError(c, err, "Failed to remove cart item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cart item removed successfully"})
+}
+
+// deleteCart deletes a cart, which is also how an abandoned cart is discarded.
+func deleteCart(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	if err := salesforceClient(c, apiVersions.commerce).DeleteCart(c.Request.Context(), creds.webstoreId, c.Param("cartId"), accountID); err != nil {
+		respondError(c, err, "Failed to delete cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cart deleted successfully"})
+}
+
+// applyCoupon applies the coupon code in a body like {"couponCode": "SPRING10"}.
+func applyCoupon(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	var requestBody struct {
+		CouponCode string `json:"couponCode"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.CouponCode == "" {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "couponCode is required"))
+		return
+	}
+
+	result, err := salesforceClient(c, apiVersions.commerce).ApplyCoupon(c.Request.Context(), creds.webstoreId, c.Param("cartId"), accountID, requestBody.CouponCode)
+	if err != nil {
+		respondError(c, err, "Failed to apply coupon")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Coupon applied successfully",
+		"coupons": result,
+	})
+}
+
+func getCartCoupons(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	result, err := salesforceClient(c, apiVersions.commerce).GetCartCoupons(c.Request.Context(), creds.webstoreId, c.Param("cartId"), accountID)
+	if err != nil {
+		respondError(c, err, "Failed to get cart coupons")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func removeCoupon(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+
+	if err := salesforceClient(c, apiVersions.commerce).DeleteCartCoupon(c.Request.Context(), creds.webstoreId, c.Param("cartId"), c.Param("couponId"), accountID); err != nil {
+		respondError(c, err, "Failed to remove coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon removed successfully"})
+}
+
+// getCartPromotions returns the promotions evaluated on the cart. With an
+// itemId query parameter it returns that item's price adjustments instead.
+func getCartPromotions(c *gin.Context) {
+	creds := credential(c)
+	cartID := c.Param("cartId")
+	accountID := c.Query("accountID")
+	client := salesforceClient(c, apiVersions.commerce)
+
+	if itemID := c.Query("itemId"); itemID != "" {
+		result, err := client.GetCartItemPriceAdjustments(c.Request.Context(), creds.webstoreId, cartID, itemID, accountID)
+		if err != nil {
+			respondError(c, err, "Failed to get price adjustments")
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	result, err := client.GetCartPromotions(c.Request.Context(), creds.webstoreId, cartID, accountID)
+	if err != nil {
+		respondError(c, err, "Failed to get cart promotions")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
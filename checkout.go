@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long checkout routes wait for Salesforce to finish pricing, tax and
+// shipping before answering 202. Override with CONNECTOR_CHECKOUT_TIMEOUT and
+// CONNECTOR_CHECKOUT_POLL_INTERVAL.
+const (
+	defaultCheckoutTimeout      = 30 * time.Second
+	defaultCheckoutPollInterval = time.Second
+)
+
+// getCheckoutStatus answers GET /checkout/:id with the checkout's current
+// state; wait=true polls until it is ready or the checkout timeout passes.
+func getCheckoutStatus(c *gin.Context) {
+	creds := credential(c)
+	accountID := c.Query("accountID")
+	client := salesforceClient(c, apiVersions.commerce)
+
+	if c.Query("wait") == "true" {
+		checkout, err := waitForCheckout(c, client, c.Param("id"), accountID)
+		respondCheckout(c, checkout, err, "Checkout is ready")
+		return
+	}
+	checkout, err := client.GetCheckout(c.Request.Context(), creds.webstoreId, c.Param("id"), accountID)
+	respondCheckout(c, checkout, err, "Checkout is ready")
+}
+
+func waitForCheckout(c *gin.Context, client *salesforce.Client, checkoutID, accountID string) (*salesforce.Checkout, error) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), envDuration("CONNECTOR_CHECKOUT_TIMEOUT", defaultCheckoutTimeout))
+	defer cancel()
+	return client.WaitForCheckout(ctx, credential(c).webstoreId, checkoutID, accountID, envDuration("CONNECTOR_CHECKOUT_POLL_INTERVAL", defaultCheckoutPollInterval))
+}
+
+// respondCheckout answers with a checkout's state: 200 once it is calculated,
+// 202 while Salesforce is still working on it and 422 with the calculation
+// errors when pricing, tax or shipping failed.
+func respondCheckout(c *gin.Context, checkout *salesforce.Checkout, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (err == nil && !checkout.Ready):
+		response := gin.H{"message": "Checkout is still calculating", "ready": false}
+		if checkout != nil && checkout.ID() != "" {
+			response["checkoutID"] = checkout.ID()
+		}
+		c.JSON(http.StatusAccepted, response)
+	case err != nil:
+		respondError(c, err, "Failed to get checkout")
+	case len(checkout.Errors()) > 0:
+		response := errorBody(c, http.StatusUnprocessableEntity, "Checkout calculation failed")
+		response["checkoutID"] = checkout.ID()
+		response["calculationErrors"] = checkout.Errors()
+		c.JSON(http.StatusUnprocessableEntity, response)
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"message":    message,
+			"checkoutID": checkout.ID(),
+			"ready":      true,
+			"checkout":   checkout.CommerceResponse,
+		})
+	}
+}
+
+// getDeliveryMethods answers GET /checkout/:id/deliveryMethods with each
+// delivery group's address, the methods it can be shipped with and the one
+// selected, once the checkout has been calculated.
+func getDeliveryMethods(c *gin.Context) {
+	accountID := c.Query("accountID")
+	checkout, err := waitForCheckout(c, salesforceClient(c, apiVersions.commerce), c.Param("id"), accountID)
+	if err != nil || !checkout.Ready {
+		respondCheckout(c, checkout, err, "")
+		return
+	}
+
+	groups := []gin.H{}
+	for _, group := range checkout.DeliveryGroups() {
+		groups = append(groups, gin.H{
+			"id":                       group["id"],
+			"deliveryAddress":          group["deliveryAddress"],
+			"availableDeliveryMethods": group["availableDeliveryMethods"],
+			"selectedDeliveryMethod":   group["selectedDeliveryMethod"],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"checkoutID":     checkout.ID(),
+		"deliveryGroups": groups,
+	})
+}
+
+// selectDeliveryMethod picks one of the checkout's available delivery
+// methods from a body like {"deliveryMethodId": "2Dm..."} and waits for the
+// checkout to be recalculated.
+func selectDeliveryMethod(c *gin.Context) {
+	creds := credential(c)
+	checkoutID := c.Param("id")
+	accountID := c.Query("accountID")
+
+	var requestBody struct {
+		DeliveryMethodID string `json:"deliveryMethodId"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || requestBody.DeliveryMethodID == "" {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "deliveryMethodId is required"))
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.commerce)
+	checkout, err := waitForCheckout(c, client, checkoutID, accountID)
+	if err != nil || !checkout.Ready {
+		respondCheckout(c, checkout, err, "")
+		return
+	}
+	if !checkout.HasDeliveryMethod(requestBody.DeliveryMethodID) {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Delivery method "+requestBody.DeliveryMethodID+" is not available for this checkout"))
+		return
+	}
+
+	changes := map[string]interface{}{"deliveryMethodId": requestBody.DeliveryMethodID}
+	if _, err := client.UpdateCheckout(c.Request.Context(), creds.webstoreId, checkoutID, accountID, changes); err != nil {
+		respondError(c, err, "Failed to select delivery method")
+		return
+	}
+	checkout, err = waitForCheckout(c, client, checkoutID, accountID)
+	respondCheckout(c, checkout, err, "Delivery method selected successfully")
+}
+
+// updateShippingAddress replaces the checkout's delivery address with the
+// address in the body and waits for shipping and tax to be recalculated.
+func updateShippingAddress(c *gin.Context) {
+	creds := credential(c)
+	checkoutID := c.Param("id")
+	accountID := c.Query("accountID")
+
+	address, ok := bindBody(c)
+	if !ok {
+		return
+	}
+	if len(address) == 0 {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Address is empty"))
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.commerce)
+	changes := map[string]interface{}{"deliveryAddress": address}
+	if _, err := client.UpdateCheckout(c.Request.Context(), creds.webstoreId, checkoutID, accountID, changes); err != nil {
+		respondError(c, err, "Failed to update shipping address")
+		return
+	}
+	checkout, err := waitForCheckout(c, client, checkoutID, accountID)
+	respondCheckout(c, checkout, err, "Shipping address updated successfully")
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many 200-record chunks of one batch run at the same time. Override
+// with CONNECTOR_COLLECTIONS_CONCURRENCY.
+const defaultCollectionsConcurrency = 4
+
+type collectionBatch struct {
+	// AllOrNone applies within each chunk of 200; chunks commit independently
+	AllOrNone bool                     `json:"allOrNone"`
+	Records   []map[string]interface{} `json:"records"`
+	IDs       []string                 `json:"ids"`
+}
+
+// collectionResult is the outcome for one record, at its index in the request.
+type collectionResult struct {
+	Index   int                `json:"index"`
+	ID      string             `json:"id,omitempty"`
+	Success bool               `json:"success"`
+	Errors  []salesforce.Error `json:"errors"`
+}
+
+func createRecords(c *gin.Context) {
+	runCollection(c, http.MethodPost)
+}
+
+func updateRecords(c *gin.Context) {
+	runCollection(c, http.MethodPatch)
+}
+
+func deleteRecords(c *gin.Context) {
+	runCollection(c, http.MethodDelete)
+}
+
+// runCollection answers the /collections/:type routes: any number of records
+// is split into sObject Collections calls of up to 200, run concurrently.
+func runCollection(c *gin.Context, method string) {
+	sobjectType := c.Param("type")
+	var batch collectionBatch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid JSON"))
+		return
+	}
+
+	size := len(batch.Records)
+	var fields []string
+	if method == http.MethodDelete {
+		size = len(batch.IDs)
+	} else {
+		seen := map[string]bool{}
+		for i, record := range batch.Records {
+			if method == http.MethodPatch && record["Id"] == nil {
+				c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Record "+strconv.Itoa(i)+" has no Id"))
+				return
+			}
+			for f := range record {
+				if !seen[f] {
+					seen[f] = true
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+	if size == 0 {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "No records given"))
+		return
+	}
+	if !checkSObjectAccess(c, sobjectType, fields) {
+		return
+	}
+	if method != http.MethodDelete && !validRecords(c, sobjectType, method, batch.Records) {
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.sobjects)
+	ctx := c.Request.Context()
+
+	// Deletes only carry IDs, so make sure they really are records of the allowed type
+	if method == http.MethodDelete {
+		prefix, err := client.KeyPrefix(ctx, sobjectType)
+		if err != nil {
+			respondError(c, err, "Failed to describe "+sobjectType)
+			return
+		}
+		for _, id := range batch.IDs {
+			if prefix == "" || !strings.HasPrefix(id, prefix) {
+				c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, id+" is not a "+sobjectType+" ID"))
+				return
+			}
+		}
+	}
+
+	results := make([]collectionResult, size)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, envInt("CONNECTOR_COLLECTIONS_CONCURRENCY", defaultCollectionsConcurrency))
+	for start := 0; start < size; start += salesforce.MaxCollectionSize {
+		end := min(start+salesforce.MaxCollectionSize, size)
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			saved, err := saveChunk(ctx, client, method, sobjectType, batch, start, end)
+			for i := start; i < end; i++ {
+				results[i] = chunkResult(i, saved, i-start, err)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"successCount": succeeded,
+		"failureCount": size - succeeded,
+		"results":      results,
+	})
+}
+
+// validRecords checks every record against sobjectType's describe and answers
+// 400 with the problems of each record that doesn't fit, by index, before any
+// chunk is sent.
+func validRecords(c *gin.Context, sobjectType, method string, records []map[string]interface{}) bool {
+	describe, ok := payloadDescribe(c, sobjectType)
+	if !ok || describe == nil {
+		return ok
+	}
+	op := salesforce.OpCreate
+	if method == http.MethodPatch {
+		op = salesforce.OpUpdate
+	}
+	var invalid []collectionResult
+	for i, record := range records {
+		if op == salesforce.OpUpdate {
+			// the Id picks the record to update, it isn't written
+			record = maps.Clone(record)
+			delete(record, "Id")
+		}
+		if problems := describe.Validate(record, op); len(problems) > 0 {
+			invalid = append(invalid, collectionResult{Index: i, Errors: problems})
+		}
+	}
+	if len(invalid) > 0 {
+		response := errorBody(c, http.StatusBadRequest, "Invalid "+sobjectType+" records")
+		response["results"] = invalid
+		c.JSON(http.StatusBadRequest, response)
+		return false
+	}
+	return true
+}
+
+func saveChunk(ctx context.Context, client *salesforce.Client, method, sobjectType string, batch collectionBatch, start, end int) ([]salesforce.SaveResult, error) {
+	switch method {
+	case http.MethodPost:
+		return client.CreateRecords(ctx, sobjectType, batch.AllOrNone, batch.Records[start:end])
+	case http.MethodPatch:
+		return client.UpdateRecords(ctx, sobjectType, batch.AllOrNone, batch.Records[start:end])
+	default:
+		return client.DeleteRecords(ctx, batch.AllOrNone, batch.IDs[start:end])
+	}
+}
+
+// chunkResult picks record index's outcome out of its chunk's answer. When
+// the whole call failed, every record of the chunk carries that error.
+func chunkResult(index int, saved []salesforce.SaveResult, offset int, err error) collectionResult {
+	if err != nil {
+		return collectionResult{Index: index, Errors: errorList(err)}
+	}
+	if offset >= len(saved) {
+		return collectionResult{Index: index, Errors: []salesforce.Error{{Message: "no result returned for record"}}}
+	}
+	r := saved[offset]
+	if r.Errors == nil {
+		r.Errors = []salesforce.Error{}
+	}
+	return collectionResult{Index: index, ID: r.ID, Success: r.Success, Errors: r.Errors}
+}
+
+// errorList turns a failed call into the errors reported for a record.
+func errorList(err error) []salesforce.Error {
+	var apiErr *salesforce.APIError
+	if errors.As(err, &apiErr) && len(apiErr.Errors) > 0 {
+		return apiErr.Errors
+	}
+	return []salesforce.Error{{Message: err.Error()}}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compositeOperation is one step of a POST /composite batch. Later steps can
+// use earlier results through @{referenceId.id} in id or body values.
+type compositeOperation struct {
+	ReferenceID string `json:"referenceId"`
+	Method      string `json:"method"`
+	SObject     string `json:"sobject"`
+	ID          string `json:"id"`
+	// ExternalIDField with ID makes a PATCH an upsert by external ID
+	ExternalIDField string                 `json:"externalIdField"`
+	Body            map[string]interface{} `json:"body"`
+}
+
+type compositeBatch struct {
+	AllOrNone  bool                 `json:"allOrNone"`
+	Operations []compositeOperation `json:"operations"`
+}
+
+var (
+	referenceIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// e.g. @{newProduct.id} or @{lookup.records[0].Id}
+	referencePattern = regexp.MustCompile(`^@\{[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z0-9_]+(\[[0-9]+\])?)*\}$`)
+)
+
+// runComposite answers POST /composite: a batch of dependent sObject writes
+// sent to Salesforce's composite resource in one call, e.g. a category, a
+// product and the ProductCategoryProduct linking them.
+func runComposite(c *gin.Context) {
+	var batch compositeBatch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid JSON"))
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.sobjects)
+	subrequests, err := compositeSubrequests(client, batch.Operations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+	allowlist := credential(c).sobjects
+	for i, op := range batch.Operations {
+		fields := bodyFields(op.Body)
+		if op.ExternalIDField != "" {
+			fields = append(fields, op.ExternalIDField)
+		}
+		if !checkSObjectAccess(c, op.SObject, fields) {
+			return
+		}
+		if fields := allowlist.readFields(op.SObject, nil); fields != nil && subrequests[i].Method == http.MethodGet {
+			subrequests[i].URL += "?fields=" + url.QueryEscape(strings.Join(fields, ","))
+		}
+	}
+
+	if !validOperations(c, batch.Operations) {
+		return
+	}
+
+	responses, err := client.Composite(c.Request.Context(), batch.AllOrNone, subrequests)
+	if err != nil {
+		respondError(c, err, "Failed to run composite request")
+		return
+	}
+
+	success := true
+	results := make([]gin.H, 0, len(responses))
+	for _, r := range responses {
+		ok := r.HTTPStatusCode >= 200 && r.HTTPStatusCode < 300
+		success = success && ok
+		results = append(results, gin.H{
+			"referenceId": r.ReferenceID,
+			"status":      r.HTTPStatusCode,
+			"success":     ok,
+			"body":        r.Body,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"allOrNone": batch.AllOrNone,
+		"success":   success,
+		"results":   results,
+	})
+}
+
+// validOperations checks the bodies of the batch's writes against their
+// describes and answers 400 listing every operation that doesn't fit.
+func validOperations(c *gin.Context, operations []compositeOperation) bool {
+	var invalid []gin.H
+	for _, op := range operations {
+		method := strings.ToUpper(op.Method)
+		if method != http.MethodPost && method != http.MethodPatch {
+			continue
+		}
+		describe, ok := payloadDescribe(c, op.SObject)
+		if !ok {
+			return false
+		}
+		if describe == nil {
+			return true
+		}
+		if problems := operationProblems(describe, op); len(problems) > 0 {
+			invalid = append(invalid, gin.H{"referenceId": op.ReferenceID, "errors": problems})
+		}
+	}
+	if len(invalid) > 0 {
+		response := errorBody(c, http.StatusBadRequest, "Invalid composite payload")
+		response["operations"] = invalid
+		c.JSON(http.StatusBadRequest, response)
+		return false
+	}
+	return true
+}
+
+// operationProblems validates a POST or PATCH body. Values that are
+// @{referenceId...} references can only be checked by Salesforce once they
+// are resolved, so only their field's existence and writability are.
+func operationProblems(describe *salesforce.Describe, op compositeOperation) []salesforce.Error {
+	writeOp := salesforce.OpCreate
+	if strings.EqualFold(op.Method, http.MethodPatch) {
+		writeOp = salesforce.OpUpdate
+		if op.ExternalIDField != "" {
+			writeOp = salesforce.OpUpsert
+		}
+	}
+	var problems []salesforce.Error
+	for _, problem := range describe.Validate(op.Body, writeOp) {
+		if len(problem.Fields) == 1 && problem.ErrorCode != "INVALID_FIELD" && problem.ErrorCode != "INVALID_FIELD_FOR_INSERT_UPDATE" {
+			if value, ok := fieldValue(op.Body, problem.Fields[0]).(string); ok && referencePattern.MatchString(value) {
+				continue
+			}
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+func compositeSubrequests(client *salesforce.Client, operations []compositeOperation) ([]salesforce.CompositeSubrequest, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("operations is empty")
+	}
+	if len(operations) > salesforce.MaxCompositeSubrequests {
+		return nil, fmt.Errorf("at most %d operations are allowed", salesforce.MaxCompositeSubrequests)
+	}
+
+	seen := map[string]bool{}
+	subrequests := make([]salesforce.CompositeSubrequest, 0, len(operations))
+	for i, op := range operations {
+		if !referenceIDPattern.MatchString(op.ReferenceID) || seen[op.ReferenceID] {
+			return nil, fmt.Errorf("operation %d needs a unique alphanumeric referenceId", i)
+		}
+		seen[op.ReferenceID] = true
+		if !salesforce.IsValidName(op.SObject) {
+			return nil, fmt.Errorf("operation %s has an invalid sobject", op.ReferenceID)
+		}
+
+		method := strings.ToUpper(op.Method)
+		path := "/sobjects/" + op.SObject
+		switch method {
+		case http.MethodPost:
+			if op.ID != "" {
+				return nil, fmt.Errorf("operation %s: POST creates a record and takes no id", op.ReferenceID)
+			}
+		case http.MethodGet, http.MethodPatch, http.MethodDelete:
+			if op.ID == "" {
+				return nil, fmt.Errorf("operation %s: %s needs an id", op.ReferenceID, method)
+			}
+			if op.ExternalIDField != "" {
+				if method != http.MethodPatch || !salesforce.IsValidName(op.ExternalIDField) {
+					return nil, fmt.Errorf("operation %s: externalIdField is only valid on PATCH", op.ReferenceID)
+				}
+				path += "/" + op.ExternalIDField
+			}
+			path += "/" + escapeReference(op.ID)
+		default:
+			return nil, fmt.Errorf("operation %s has an unsupported method %q", op.ReferenceID, op.Method)
+		}
+
+		subrequest := salesforce.CompositeSubrequest{
+			Method:      method,
+			URL:         client.DataPath(path),
+			ReferenceID: op.ReferenceID,
+		}
+		if method == http.MethodPost || method == http.MethodPatch {
+			subrequest.Body = op.Body
+		}
+		subrequests = append(subrequests, subrequest)
+	}
+	return subrequests, nil
+}
+
+// escapeReference path-escapes an id but keeps @{ref.field} intact for Salesforce to resolve.
+func escapeReference(id string) string {
+	if referencePattern.MatchString(id) {
+		return id
+	}
+	return url.PathEscape(id)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Object metadata rarely changes, so describes are kept for a while per org.
+// Override with CONNECTOR_DESCRIBE_TTL; set CONNECTOR_VALIDATE_PAYLOADS=false
+// to send payloads to Salesforce unchecked.
+const defaultDescribeTTL = time.Hour
+
+type cachedDescribe struct {
+	describe  *salesforce.Describe
+	fetchedAt time.Time
+}
+
+// describeCache holds describes by credentials, API version and object type.
+type describeCache struct {
+	mu        sync.Mutex
+	describes map[string]cachedDescribe
+}
+
+var describes = &describeCache{describes: map[string]cachedDescribe{}}
+
+// get returns the cached describe of sobjectType for client's org, fetching
+// it when missing or older than the TTL.
+func (dc *describeCache) get(ctx context.Context, cred credentials, client *salesforce.Client, sobjectType string) (*salesforce.Describe, error) {
+	key := tokenCacheKey(cred) + "|" + client.APIVersion + "|" + strings.ToLower(sobjectType)
+
+	dc.mu.Lock()
+	cached, ok := dc.describes[key]
+	dc.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < envDuration("CONNECTOR_DESCRIBE_TTL", defaultDescribeTTL) {
+		return cached.describe, nil
+	}
+
+	describe, err := client.Describe(ctx, sobjectType)
+	if err != nil {
+		return nil, err
+	}
+	dc.mu.Lock()
+	dc.describes[key] = cachedDescribe{describe: describe, fetchedAt: time.Now()}
+	dc.mu.Unlock()
+	return describe, nil
+}
+
+// validBody checks a create or update payload against sobjectType's describe
+// and answers 400 with every violation when it doesn't fit, so mistakes come
+// back before a round-trip to Salesforce.
+func validBody(c *gin.Context, sobjectType string, body map[string]interface{}, op salesforce.WriteOp) bool {
+	describe, ok := payloadDescribe(c, sobjectType)
+	if !ok || describe == nil {
+		return ok
+	}
+	if problems := describe.Validate(body, op); len(problems) > 0 {
+		response := errorBody(c, http.StatusBadRequest, "Invalid "+sobjectType+" payload")
+		response["errors"] = problems
+		c.JSON(http.StatusBadRequest, response)
+		return false
+	}
+	return true
+}
+
+// payloadDescribe returns the describe to validate sobjectType payloads
+// against, or nil when validation is turned off. When the describe can't be
+// fetched it answers the request and returns false.
+func payloadDescribe(c *gin.Context, sobjectType string) (*salesforce.Describe, bool) {
+	if os.Getenv("CONNECTOR_VALIDATE_PAYLOADS") == "false" {
+		return nil, true
+	}
+	describe, err := describes.get(c.Request.Context(), credential(c), salesforceClient(c, apiVersions.sobjects), sobjectType)
+	if err != nil {
+		respondError(c, err, "Failed to describe "+sobjectType)
+		return nil, false
+	}
+	return describe, true
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Records fetched per result page of an export. Override with
+// CONNECTOR_BULK_EXPORT_PAGE_SIZE.
+const defaultExportPageSize = 50000
+
+// runExport answers GET /export. It takes the same sobject, fields, filter,
+// order and limit parameters as GET /query, runs them as a Bulk API 2.0 query
+// job and streams every result page back as they arrive, so exports of any
+// size never sit in memory. format=ndjson turns each CSV row into a JSON
+// object, with empty values as null.
+//
+// The status line is sent once the job has completed; if fetching a later
+// page fails the response is cut short and the error is only logged.
+func runExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "format must be csv or ndjson"))
+		return
+	}
+	soql, sobjectType, fields, err := buildQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if !checkSObjectAccess(c, sobjectType, fields) {
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.query)
+	ctx := c.Request.Context()
+	job, err := client.CreateQueryJob(ctx, soql)
+	if err != nil {
+		respondError(c, err, "Failed to start export")
+		return
+	}
+	if job, err = waitForQueryJob(ctx, client, job.ID); err != nil {
+		abortQueryJob(client, job.ID)
+		respondError(c, err, "Failed to run export")
+		return
+	}
+	if job.State != salesforce.JobStateJobComplete {
+		c.JSON(http.StatusBadGateway, errorBody(c, http.StatusBadGateway, "Export job "+job.State+": "+job.ErrorMessage))
+		return
+	}
+
+	contentType := "text/csv"
+	if format == "ndjson" {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+sobjectType+"-"+job.ID+"."+format+`"`)
+	c.Status(http.StatusOK)
+
+	locator := ""
+	for page := 0; page == 0 || locator != ""; page++ {
+		results, next, err := client.QueryJobResults(ctx, job.ID, locator, envInt("CONNECTOR_BULK_EXPORT_PAGE_SIZE", defaultExportPageSize))
+		if err != nil {
+			log.Printf("export %s: fetching page %d: %v", job.ID, page, err)
+			return
+		}
+		if format == "ndjson" {
+			err = writeNDJSON(c.Writer, results)
+		} else {
+			err = writeCSVPage(c.Writer, results, page > 0)
+		}
+		results.Close()
+		if err != nil {
+			log.Printf("export %s: streaming page %d: %v", job.ID, page, err)
+			return
+		}
+		c.Writer.Flush()
+		locator = next
+	}
+}
+
+// waitForQueryJob polls the job until it leaves the queue. The job is
+// returned with the error so the caller can still abort it.
+func waitForQueryJob(ctx context.Context, client *salesforce.Client, jobID string) (*salesforce.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, envDuration("CONNECTOR_BULK_TIMEOUT", defaultBulkTimeout))
+	defer cancel()
+	ticker := time.NewTicker(envDuration("CONNECTOR_BULK_POLL_INTERVAL", defaultBulkPollInterval))
+	defer ticker.Stop()
+	for {
+		job, err := client.GetQueryJob(ctx, jobID)
+		if err != nil {
+			return &salesforce.Job{ID: jobID}, err
+		}
+		if job.Finished() {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// abortQueryJob stops a job nobody will read anymore, e.g. because the caller
+// hung up.
+func abortQueryJob(client *salesforce.Client, jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := client.AbortQueryJob(ctx, jobID); err != nil {
+		log.Printf("aborting query job %s: %v", jobID, err)
+	}
+}
+
+// writeCSVPage copies a page of results, dropping the header row every page
+// after the first repeats.
+func writeCSVPage(w io.Writer, page io.Reader, skipHeader bool) error {
+	reader := bufio.NewReader(page)
+	if skipHeader {
+		if _, err := reader.ReadString('\n'); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	_, err := io.Copy(w, reader)
+	return err
+}
+
+// writeNDJSON writes each row of a CSV page as a JSON object keyed by the
+// page's header row.
+func writeNDJSON(w io.Writer, page io.Reader) error {
+	reader := csv.NewReader(page)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header = append([]string(nil), header...)
+
+	encoder := json.NewEncoder(w)
+	row := make(map[string]interface{}, len(header))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i, field := range header {
+			if i < len(record) && record[i] != "" {
+				row[field] = record[i]
+			} else {
+				row[field] = nil
+			}
+		}
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+}
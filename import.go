@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Import limits. Rows are saved one call each, so keep files to a size a
+// request can finish; use the Bulk API jobs for nightly loads. Override with
+// CONNECTOR_IMPORT_MAX_ROWS and CONNECTOR_IMPORT_CONCURRENCY.
+const (
+	defaultImportMaxRows     = 2000
+	defaultImportConcurrency = 4
+	// category names per lookup query, to keep the URL short
+	categoryLookupSize = 100
+)
+
+// importMapping says how the columns of a merchandiser's CSV become products.
+type importMapping struct {
+	// Columns maps CSV headers to Product2 fields; other columns are ignored
+	Columns map[string]string `json:"columns"`
+	// Category is the column holding the name of the product's category
+	Category string `json:"category"`
+	// Mode is "create" (default) or "upsert" on the Product2 external ID field
+	Mode string `json:"mode"`
+	// Required lists Product2 fields that must have a value in every row
+	Required []string `json:"required"`
+}
+
+// importRow is one data row of the file, numbered as its line in the file.
+type importRow struct {
+	line     int
+	fields   map[string]interface{}
+	category string
+}
+
+type importResult struct {
+	Row        int                `json:"row"`
+	Status     string             `json:"status"`
+	ID         string             `json:"id,omitempty"`
+	CategoryID string             `json:"categoryId,omitempty"`
+	Errors     []salesforce.Error `json:"errors"`
+}
+
+// importProducts answers POST /import/products. The multipart body carries
+// the CSV as "file" and the importMapping as JSON in "mapping". Every row is
+// validated first; valid rows are then created through the composite
+// products API like createProduct, or upserted by external ID. With
+// dryRun=true nothing is saved. The answer reports each row's outcome.
+func importProducts(c *gin.Context) {
+	var mapping importMapping
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "mapping must be a JSON column mapping"))
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "file is required"))
+		return
+	}
+	upload, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Failed to read file"))
+		return
+	}
+	defer upload.Close()
+
+	cred := credential(c)
+	externalIDField := cred.externalIDs["product2"]
+	if err := checkImportMapping(&mapping, externalIDField); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if !checkSObjectAccess(c, "Product2", slices.Sorted(maps.Values(mapping.Columns))) {
+		return
+	}
+	rows, err := readImportRows(upload, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	categories, err := resolveCategories(c, rows)
+	if err != nil {
+		respondError(c, err, "Failed to look up categories")
+		return
+	}
+
+	describe, ok := payloadDescribe(c, "Product2")
+	if !ok {
+		return
+	}
+
+	results := make([]importResult, len(rows))
+	var valid []int
+	seen := map[string]int{}
+	for i, row := range rows {
+		results[i] = validateImportRow(row, mapping, describe, externalIDField, categories, seen)
+		if len(results[i].Errors) == 0 {
+			valid = append(valid, i)
+		}
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	if !dryRun {
+		client := salesforceClient(c, apiVersions.commerce)
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, envInt("CONNECTOR_IMPORT_CONCURRENCY", defaultImportConcurrency))
+		for _, i := range valid {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				if mapping.Mode == "upsert" {
+					results[i] = upsertImportRow(ctx, client, rows[i], externalIDField, results[i])
+				} else {
+					results[i] = createImportRow(ctx, client, cred.webstoreId, rows[i], results[i])
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	succeeded := 0
+	for _, r := range results {
+		if len(r.Errors) == 0 {
+			succeeded++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mode":      mapping.Mode,
+		"dryRun":    dryRun,
+		"total":     len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"rows":      results,
+	})
+}
+
+func checkImportMapping(mapping *importMapping, externalIDField string) error {
+	if mapping.Mode == "" {
+		mapping.Mode = "create"
+	}
+	if mapping.Mode != "create" && mapping.Mode != "upsert" {
+		return errors.New("mode must be create or upsert")
+	}
+	if len(mapping.Columns) == 0 {
+		return errors.New("mapping has no columns")
+	}
+	mapped := map[string]bool{}
+	for column, field := range mapping.Columns {
+		if !salesforce.IsValidName(field) {
+			return errors.New("column " + column + " maps to an invalid field name " + field)
+		}
+		mapped[strings.ToLower(field)] = true
+	}
+	if mapping.Mode == "upsert" {
+		if externalIDField == "" {
+			return errors.New("no external ID field configured for Product2")
+		}
+		if !mapped[strings.ToLower(externalIDField)] {
+			return errors.New("upserts need a column mapped to " + externalIDField)
+		}
+		mapping.Required = append(mapping.Required, externalIDField)
+	} else {
+		mapping.Required = append(mapping.Required, "Name")
+	}
+	for _, field := range mapping.Required {
+		if !mapped[strings.ToLower(field)] {
+			return errors.New("required field " + field + " is not mapped to a column")
+		}
+	}
+	return nil
+}
+
+// readImportRows parses the CSV into Product2 field values. Empty cells are
+// left out rather than sent as blanks.
+func readImportRows(file io.Reader, mapping importMapping) ([]importRow, error) {
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("file must be CSV with a header row")
+	}
+	positions := map[string]int{}
+	for i, column := range header {
+		positions[strings.TrimSpace(column)] = i
+	}
+	for column := range mapping.Columns {
+		if _, ok := positions[column]; !ok {
+			return nil, errors.New("column " + column + " is not in the file")
+		}
+	}
+	if _, ok := positions[mapping.Category]; mapping.Category != "" && !ok {
+		return nil, errors.New("category column " + mapping.Category + " is not in the file")
+	}
+
+	maxRows := envInt("CONNECTOR_IMPORT_MAX_ROWS", defaultImportMaxRows)
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxRows {
+			return nil, errors.New("file has more than " + strconv.Itoa(maxRows) + " rows")
+		}
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line, fields: map[string]interface{}{}}
+		for column, field := range mapping.Columns {
+			if value := strings.TrimSpace(record[positions[column]]); value != "" {
+				row.fields[field] = value
+			}
+		}
+		if mapping.Category != "" {
+			row.category = strings.TrimSpace(record[positions[mapping.Category]])
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no rows")
+	}
+	return rows, nil
+}
+
+// resolveCategories looks up the IDs of every category named in the file.
+// Names are matched case-insensitively, as SOQL does; a name can belong to
+// more than one category.
+func resolveCategories(c *gin.Context, rows []importRow) (map[string][]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if key := strings.ToLower(row.category); row.category != "" && !seen[key] {
+			seen[key] = true
+			names = append(names, row.category)
+		}
+	}
+
+	categories := map[string][]string{}
+	client := salesforceClient(c, apiVersions.query)
+	for start := 0; start < len(names); start += categoryLookupSize {
+		chunk := names[start:min(start+categoryLookupSize, len(names))]
+		soql, err := salesforce.Select("Id", "Name").From("ProductCategory").Where("Name", "IN", chunk).Build()
+		if err != nil {
+			return nil, err
+		}
+		result, err := client.Query(c.Request.Context(), soql)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range result.Records {
+			name, _ := record["Name"].(string)
+			id, _ := record["Id"].(string)
+			categories[strings.ToLower(name)] = append(categories[strings.ToLower(name)], id)
+		}
+	}
+	return categories, nil
+}
+
+// validateImportRow checks a row before anything is saved: the mapping's
+// required fields, duplicate external IDs, its category and, unless payload
+// validation is off (describe is nil), the Product2 describe.
+func validateImportRow(row importRow, mapping importMapping, describe *salesforce.Describe, externalIDField string, categories map[string][]string, seen map[string]int) importResult {
+	result := importResult{Row: row.line, Status: "valid", Errors: []salesforce.Error{}}
+	for _, field := range mapping.Required {
+		if fieldValue(row.fields, field) == nil {
+			result.Errors = append(result.Errors, salesforce.Error{Message: "Required field is empty", Fields: []string{field}})
+		}
+	}
+	if describe != nil {
+		op := salesforce.OpCreate
+		if mapping.Mode == "upsert" {
+			op = salesforce.OpUpsert
+		}
+		result.Errors = append(result.Errors, describe.Validate(row.fields, op)...)
+	}
+	if mapping.Mode == "upsert" {
+		if value, ok := fieldValue(row.fields, externalIDField).(string); ok {
+			if first, dup := seen[value]; dup {
+				result.Errors = append(result.Errors, salesforce.Error{
+					Message: "Duplicate of row " + strconv.Itoa(first),
+					Fields:  []string{externalIDField},
+				})
+			} else {
+				seen[value] = row.line
+			}
+		}
+	}
+	if row.category != "" {
+		switch ids := categories[strings.ToLower(row.category)]; len(ids) {
+		case 0:
+			result.Errors = append(result.Errors, salesforce.Error{Message: "Unknown category " + row.category})
+		case 1:
+			result.CategoryID = ids[0]
+		default:
+			result.Errors = append(result.Errors, salesforce.Error{Message: "More than one category is named " + row.category})
+		}
+	}
+	if len(result.Errors) > 0 {
+		result.Status = "invalid"
+	}
+	return result
+}
+
+// createImportRow saves a row through the composite products API, which
+// assigns the category in the same call.
+func createImportRow(ctx context.Context, client *salesforce.Client, webstoreID string, row importRow, result importResult) importResult {
+	product := map[string]interface{}{
+		"product": map[string]interface{}{"fieldValues": row.fields},
+	}
+	if result.CategoryID != "" {
+		product["productCategoryProduct"] = map[string]interface{}{
+			"fieldValues": map[string]interface{}{"ProductCategoryId": result.CategoryID},
+		}
+	}
+	response, err := client.CreateCompositeProduct(ctx, webstoreID, product)
+	if err != nil {
+		result.Status = "failed"
+		result.Errors = errorList(err)
+		return result
+	}
+	result.Status = "created"
+	result.ID = compositeProductID(response)
+	return result
+}
+
+// upsertImportRow upserts the Product2 record by external ID. Categories are
+// only assigned to products the upsert created; existing products keep theirs.
+func upsertImportRow(ctx context.Context, client *salesforce.Client, row importRow, externalIDField string, result importResult) importResult {
+	fields := make(map[string]interface{}, len(row.fields))
+	for field, value := range row.fields {
+		if !strings.EqualFold(field, externalIDField) {
+			fields[field] = value
+		}
+	}
+	value, _ := fieldValue(row.fields, externalIDField).(string)
+	saved, err := client.WithAPIVersion(apiVersions.sobjects).UpsertSObject(ctx, "Product2", externalIDField, value, fields)
+	if err != nil {
+		result.Status = "failed"
+		result.Errors = errorList(err)
+		return result
+	}
+	result.ID = saved.ID
+	if !saved.Created {
+		result.Status = "updated"
+		return result
+	}
+	result.Status = "created"
+	if result.CategoryID != "" {
+		link := map[string]interface{}{"ProductCategoryId": result.CategoryID, "ProductId": saved.ID}
+		if _, err := client.WithAPIVersion(apiVersions.sobjects).CreateSObject(ctx, "ProductCategoryProduct", link); err != nil {
+			result.Errors = errorList(err)
+		}
+	}
+	return result
+}
+
+// fieldValue looks a field up by API name, which Salesforce treats case-insensitively.
+func fieldValue(fields map[string]interface{}, name string) interface{} {
+	for field, value := range fields {
+		if strings.EqualFold(field, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+// compositeProductID digs the new product's ID out of a composite products answer.
+func compositeProductID(response salesforce.CommerceResponse) string {
+	if product, ok := response["product"].(map[string]interface{}); ok {
+		if id, ok := product["id"].(string); ok {
+			return id
+		}
+	}
+	id, _ := response["id"].(string)
+	return id
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bulk ingest defaults. Override with CONNECTOR_BULK_POLL_INTERVAL,
+// CONNECTOR_BULK_TIMEOUT (durations) and CONNECTOR_BULK_MAX_UPLOAD (bytes).
+// Salesforce takes at most 150MB of CSV per job.
+const (
+	defaultBulkPollInterval = 5 * time.Second
+	defaultBulkTimeout      = 2 * time.Hour
+	defaultBulkMaxUpload    = 100 << 20
+	// finished jobs are forgotten after this long
+	bulkJobRetention = 24 * time.Hour
+)
+
+var ingestOperations = map[string]bool{
+	"insert": true, "update": true, "upsert": true, "delete": true, "hardDelete": true,
+}
+
+// ingest result sets by the name used in /jobs/:id/results/:kind
+var ingestResultSets = map[string]string{
+	"successful":  salesforce.IngestResultsSuccessful,
+	"failed":      salesforce.IngestResultsFailed,
+	"unprocessed": salesforce.IngestResultsUnprocessed,
+}
+
+// ingestJob is the connector's record of a Bulk API 2.0 ingest job, kept up
+// to date by a background poller.
+type ingestJob struct {
+	ID               string    `json:"id"`
+	Object           string    `json:"object"`
+	Operation        string    `json:"operation"`
+	ExternalIDField  string    `json:"externalIdField,omitempty"`
+	State            string    `json:"state"`
+	RecordsProcessed int       `json:"recordsProcessed"`
+	RecordsFailed    int       `json:"recordsFailed"`
+	ErrorMessage     string    `json:"errorMessage,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	// Finished is set once the job completed, failed, was aborted or polling gave up
+	Finished bool `json:"finished"`
+
+	cred credentials
+}
+
+// jobStore holds the jobs started by this process. It lives in memory, so a
+// restart loses track of running jobs; they still finish in Salesforce.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*ingestJob
+}
+
+var bulkJobs = &jobStore{jobs: map[string]*ingestJob{}}
+
+func (s *jobStore) add(job *ingestJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, j := range s.jobs {
+		if j.Finished && time.Since(j.UpdatedAt) > bulkJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[job.ID] = job
+}
+
+// get returns a copy of the job if it was started with the same credentials.
+func (s *jobStore) get(id string, cred credentials) (ingestJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || tokenCacheKey(job.cred) != tokenCacheKey(cred) {
+		return ingestJob{}, false
+	}
+	return *job, true
+}
+
+// update copies Salesforce's view of the job into the local record.
+func (s *jobStore) update(id string, info *salesforce.Job, errorMessage string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	if info != nil {
+		job.State = info.State
+		job.RecordsProcessed = info.NumberRecordsProcessed
+		job.RecordsFailed = info.NumberRecordsFailed
+		job.ErrorMessage = info.ErrorMessage
+		job.Finished = info.Finished()
+	}
+	if errorMessage != "" {
+		job.ErrorMessage = errorMessage
+		job.Finished = true
+	}
+	job.UpdatedAt = time.Now()
+}
+
+// createIngestJob answers POST /jobs/ingest/:type. The body is the CSV to
+// load; its header row names the fields. The job is created, uploaded and
+// closed before answering 202, and processing is then tracked in the
+// background. Rows may end in LF or CRLF, as detected from the header row;
+// lineEnding=LF or CRLF overrides the detection.
+func createIngestJob(c *gin.Context) {
+	cred := credential(c)
+	sobjectType := c.Param("type")
+	operation := c.DefaultQuery("operation", "insert")
+	if !ingestOperations[operation] {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Unsupported operation "+operation))
+		return
+	}
+	externalIDField := ""
+	if operation == "upsert" {
+		externalIDField = c.DefaultQuery("externalIdField", cred.externalIDs[strings.ToLower(sobjectType)])
+		if externalIDField == "" {
+			c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "No external ID field given or configured for "+sobjectType))
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, int64(envInt("CONNECTOR_BULK_MAX_UPLOAD", defaultBulkMaxUpload))))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, errorBody(c, http.StatusRequestEntityTooLarge, "CSV is larger than "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Failed to read request body"))
+		return
+	}
+	header, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Body must be CSV with a header row"))
+		return
+	}
+	if !checkSObjectAccess(c, sobjectType, header) {
+		return
+	}
+	lineEnding := strings.ToUpper(c.Query("lineEnding"))
+	switch lineEnding {
+	case "":
+		lineEnding = detectLineEnding(data)
+	case salesforce.LineEndingLF, salesforce.LineEndingCRLF:
+	default:
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "lineEnding must be LF or CRLF"))
+		return
+	}
+
+	client := salesforceClient(c, apiVersions.sobjects)
+	ctx := c.Request.Context()
+	info, err := client.CreateIngestJob(ctx, sobjectType, operation, externalIDField, lineEnding)
+	if err != nil {
+		respondError(c, err, "Failed to create ingest job")
+		return
+	}
+	jobID := info.ID
+	if err := client.UploadJobData(ctx, jobID, data); err != nil {
+		abortIngestJob(client, jobID)
+		respondError(c, err, "Failed to upload job data")
+		return
+	}
+	if info, err = client.SetIngestJobState(ctx, jobID, salesforce.JobStateUploadComplete); err != nil {
+		abortIngestJob(client, jobID)
+		respondError(c, err, "Failed to close ingest job")
+		return
+	}
+
+	now := time.Now()
+	job := &ingestJob{
+		ID:              jobID,
+		Object:          sobjectType,
+		Operation:       operation,
+		ExternalIDField: externalIDField,
+		State:           info.State,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		cred:            cred,
+	}
+	answer := *job
+	bulkJobs.add(job)
+	go pollIngestJob(cred, jobID)
+
+	c.JSON(http.StatusAccepted, answer)
+}
+
+// detectLineEnding tells CRLF files, e.g. saved by Excel, from LF ones by how
+// the header row ends.
+func detectLineEnding(data []byte) string {
+	if end := bytes.IndexByte(data, '\n'); end > 0 && data[end-1] == '\r' {
+		return salesforce.LineEndingCRLF
+	}
+	return salesforce.LineEndingLF
+}
+
+// abortIngestJob gives up on a job that couldn't be started, so it doesn't
+// sit open in the org.
+func abortIngestJob(client *salesforce.Client, jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := client.SetIngestJobState(ctx, jobID, salesforce.JobStateAborted); err != nil {
+		log.Printf("aborting ingest job %s: %v", jobID, err)
+	}
+}
+
+// pollIngestJob follows a job until Salesforce finishes it or the bulk
+// timeout passes.
+func pollIngestJob(cred credentials, jobID string) {
+	client := newSalesforceClient(cred, apiVersions.sobjects)
+	timeout := envDuration("CONNECTOR_BULK_TIMEOUT", defaultBulkTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(envDuration("CONNECTOR_BULK_POLL_INTERVAL", defaultBulkPollInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			bulkJobs.update(jobID, nil, "Stopped polling after "+timeout.String())
+			return
+		case <-ticker.C:
+		}
+		info, err := client.GetIngestJob(ctx, jobID)
+		if err != nil {
+			// keep polling; the job itself may be fine
+			log.Printf("polling ingest job %s: %v", jobID, err)
+			continue
+		}
+		bulkJobs.update(jobID, info, "")
+		if info.Finished() {
+			return
+		}
+	}
+}
+
+// getJob answers GET /jobs/:id with the locally tracked status.
+func getJob(c *gin.Context) {
+	job, ok := bulkJobs.get(c.Param("id"), credential(c))
+	if !ok {
+		c.JSON(http.StatusNotFound, errorBody(c, http.StatusNotFound, "Job not found"))
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// getJobResults answers GET /jobs/:id/results/:kind, streaming the
+// successful, failed or unprocessed records as CSV.
+func getJobResults(c *gin.Context) {
+	resultSet, ok := ingestResultSets[c.Param("kind")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Result kind must be successful, failed or unprocessed"))
+		return
+	}
+	job, ok := bulkJobs.get(c.Param("id"), credential(c))
+	if !ok {
+		c.JSON(http.StatusNotFound, errorBody(c, http.StatusNotFound, "Job not found"))
+		return
+	}
+	if !job.Finished {
+		c.JSON(http.StatusConflict, errorBody(c, http.StatusConflict, "Job is still "+job.State))
+		return
+	}
+
+	results, err := salesforceClient(c, apiVersions.sobjects).IngestJobResults(c.Request.Context(), job.ID, resultSet)
+	if err != nil {
+		respondError(c, err, "Failed to fetch job results")
+		return
+	}
+	defer results.Close()
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+job.ID+"-"+c.Param("kind")+`.csv"`)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, results); err != nil {
+		log.Printf("streaming results of job %s: %v", job.ID, err)
+	}
+}
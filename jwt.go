@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+)
+
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	defaultJWTAudience = "https://login.salesforce.com"
+)
+
+// applyJWTDefaults fills in whatever the tenant didn't set from the
+// connector-wide CONNECTOR_JWT_* settings.
+func applyJWTDefaults(cred *credentials) {
+	if cred.jwtSubject == "" {
+		cred.jwtSubject = os.Getenv("CONNECTOR_JWT_SUBJECT")
+	}
+	if cred.jwtAudience == "" {
+		cred.jwtAudience = os.Getenv("CONNECTOR_JWT_AUDIENCE")
+	}
+	if cred.jwtAudience == "" {
+		cred.jwtAudience = defaultJWTAudience
+	}
+	if cred.jwtPrivateKey == "" && cred.jwtPrivateKeyFile == "" {
+		cred.jwtPrivateKey = os.Getenv("CONNECTOR_JWT_PRIVATE_KEY")
+		cred.jwtPrivateKeyFile = os.Getenv("CONNECTOR_JWT_PRIVATE_KEY_FILE")
+	}
+}
+
+// jwtPrivateKey loads the RSA key used to sign assertions, either inline PEM
+// or from a file. Inline keys may use literal "\n" so they fit on one .env line.
+func jwtPrivateKey(cred credentials) (*rsa.PrivateKey, error) {
+	var data []byte
+	switch {
+	case cred.jwtPrivateKey != "":
+		data = []byte(strings.ReplaceAll(cred.jwtPrivateKey, `\n`, "\n"))
+	case cred.jwtPrivateKeyFile != "":
+		var err error
+		if data, err = os.ReadFile(cred.jwtPrivateKeyFile); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("no private key configured for the JWT bearer flow")
+	}
+	return salesforce.ParsePrivateKey(data)
+}
@@ -1,125 +1,175 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-
-	//"fmt"
-
-	"io/ioutil"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
-// Struct to capture the Salesforce token response
-type AuthResponse struct {
-	AccessToken string `json:"access_token"`
-	InstanceURL string `json:"instance_url"`
-}
-
 type credentials struct {
+	tenantID     string
 	shopURL      string
 	clientId     string
 	clientSecret string
 	tokenURL     string
 	webstoreId   string
-}
 
+	// sObject types and fields the generic routes may touch
+	sobjects sobjectAllowlist
+	// field used to upsert each object type by an outside system's key
+	externalIDs externalIDFields
+
+	// JWT bearer flow; the private key never travels in request headers
+	grantType         string
+	jwtSubject        string
+	jwtAudience       string
+	jwtPrivateKey     string
+	jwtPrivateKeyFile string
+}
 
-	//_ = tokenURL
+// credential returns the Salesforce credentials resolved for this request by requireTenant
 func credential(c *gin.Context) credentials {
+	if cred, ok := c.Get(credentialsKey); ok {
+		return cred.(credentials)
+	}
+	cred, err := resolveCredentials(c)
+	if err != nil {
+		log.Println(err)
+	}
+	return cred
+}
+
+// resolveCredentials builds the request's credentials from a tenant profile or,
+// when allowed, from the shopUrl/clientId/clientSecret/webstoreId headers
+func resolveCredentials(c *gin.Context) (credentials, error) {
 	var cred credentials
 
+	// A tenant ID or API key selects a server-side profile instead of header credentials
+	if tenantID, apiKey := c.GetHeader("tenantId"), c.GetHeader("apiKey"); tenantID != "" || apiKey != "" {
+		profile, err := tenants.resolve(tenantID, apiKey)
+		if err != nil {
+			return cred, err
+		}
+		cred = profile.credentials()
+		cred.tokenURL = cred.shopURL + "/services/oauth2/token"
+		return cred, nil
+	}
+	if !tenants.allowHeaderCredentials {
+		return cred, errHeaderCredentialsDisabled
+	}
+
 	// Fetch headers from the incoming request
-	cred.shopURL = c.GetHeader("shopUrl")
+	cred.sobjects = defaultAllowlist
+	cred.externalIDs = defaultExternalIDs
+	cred.shopURL = strings.TrimSuffix(c.GetHeader("shopUrl"), "/")
 	cred.clientId = c.GetHeader("clientId")
 	cred.clientSecret = c.GetHeader("clientSecret")
 	cred.webstoreId = c.GetHeader("webstoreId")
 
-	// Validate required headers
-	if cred.shopURL == "" || cred.clientId == "" || cred.clientSecret == "" || cred.webstoreId == "" {
-		log.Println("Missing one or more required headers")
+	// Header credentials always use the client credentials flow; JWT is set up per tenant profile
+	if c.GetHeader("authFlow") != "" || c.GetHeader("jwtSubject") != "" {
+		return cred, errJWTHeaderCredentials
 	}
+	cred.grantType = grantTypeClientCredentials
 
-	// Construct the token URL
-	cred.tokenURL = cred.shopURL + "/services/oauth2/token?grant_type=client_credentials&client_id=" + cred.clientId + "&client_secret=" + cred.clientSecret
+	// Construct the token URL; the client credentials go in the request body
+	cred.tokenURL = cred.shopURL + "/services/oauth2/token"
 
-	return cred
+	return cred, nil
 }
 
-// Function to fetch access token from Salesforce OAuth 2.0 token URL
-func getAccessToken(c *gin.Context) (string, error) {
-	// Make the POST request to get the token
-	cred := credential(c)
-	var tokenURL = cred.tokenURL
-
-	response, err := http.Post(tokenURL, "application/x-www-form-urlencoded", nil)
-	if err != nil {
-		return "", err
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", err
+// errorBody is the error envelope every failed request answers with. errors
+// holds Salesforce's errorCode/message/fields entries when there are any.
+func errorBody(c *gin.Context, status int, message string) gin.H {
+	return gin.H{
+		"error":         message,
+		"status":        status,
+		"errors":        []salesforce.Error{},
+		"correlationId": c.GetString(correlationIDKey),
 	}
+}
 
-	// Unmarshal the response to extract access token
-	var authResponse AuthResponse
-	if err := json.Unmarshal(body, &authResponse); err != nil {
-		return "", err
-	}
+// respondError reports a failed Salesforce call to the client. Salesforce's own
+// status code and errorCode/message/fields list are passed through as they are.
+func respondError(c *gin.Context, err error, message string) {
+	log.Printf("[%s] %s: %v", c.GetString(correlationIDKey), message, err)
+	c.JSON(errorResponse(c, err, message))
+}
 
-	// Return the access token
-	return authResponse.AccessToken, nil
+// errorResponse builds respondError's status and body for handlers that add
+// their own details before answering.
+func errorResponse(c *gin.Context, err error, message string) (int, gin.H) {
+	status := http.StatusBadGateway
+	var apiErr *salesforce.APIError
+	var tokenErr *salesforce.TokenError
+	var oauthErr *salesforce.OAuthError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.StatusCode
+	case errors.As(err, &tokenErr):
+		// Rejected header credentials are the caller's to fix. A tenant
+		// profile's stored secret is ours, so that stays an upstream failure
+		if errors.As(err, &oauthErr) && credential(c).tenantID == "" {
+			status = http.StatusUnauthorized
+		}
+		message = "Failed to get access token"
+	}
+
+	body := errorBody(c, status, message)
+	switch {
+	case apiErr != nil:
+		if apiErr.Errors != nil {
+			body["errors"] = apiErr.Errors
+		}
+	case tokenErr != nil:
+		body["errors"] = []salesforce.Error{{Message: tokenErr.Err.Error()}}
+	}
+	return status, body
 }
 
-func getProduct(c *gin.Context) {
-	productID := c.Param("id")
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/sobjects/Product2/" + productID
-	//fmt.Println(getapiURL)
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
+// bindBody reads the request's JSON object, answering 400 when it isn't one
+func bindBody(c *gin.Context) (map[string]interface{}, bool) {
+	var requestBody map[string]interface{}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid JSON"))
+		return nil, false
 	}
+	return requestBody, true
+}
 
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
+// envInt reads a positive whole number from the environment variable name,
+// falling back to def when it is unset or invalid.
+func envInt(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
 	}
+	return def
+}
 
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
+// envDuration is envInt for durations such as "30s" or "2h".
+func envDuration(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
 	}
-	defer response.Body.Close()
+	return def
+}
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+func getProduct(c *gin.Context) {
+	productID := c.Param("id")
+	result, err := salesforceClient(c, apiVersions.sobjects).GetSObject(c.Request.Context(), "Product2", productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get product")
 		return
 	}
 
@@ -129,52 +179,15 @@ func getProduct(c *gin.Context) {
 
 // Function to create a product and associate it with a category
 func createProduct(c *gin.Context) {
-	creds := credential(c)
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/management/webstore/" + creds.webstoreId + "/composite-products"
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
-	defer response.Body.Close()
 
-	body, err := ioutil.ReadAll(response.Body)
+	creds := credential(c)
+	result, err := salesforceClient(c, apiVersions.commerce).CreateCompositeProduct(c.Request.Context(), creds.webstoreId, requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+		respondError(c, err, "Failed to create product")
 		return
 	}
 
@@ -186,123 +199,36 @@ func createProduct(c *gin.Context) {
 
 func updateProduct(c *gin.Context) {
 	productID := c.Param("id")
-
-	updateURL := credential(c).shopURL + "/services/data/v58.0/sobjects/Product2/" + productID
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	requestBody, ok := bindBody(c)
+	if !ok || !validBody(c, "Product2", requestBody, salesforce.OpUpdate) {
 		return
 	}
 
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+	if err := salesforceClient(c, apiVersions.sobjects).UpdateSObject(c.Request.Context(), "Product2", productID, requestBody); err != nil {
+		respondError(c, err, "Failed to update product")
 		return
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	req, err := http.NewRequest("PATCH", updateURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make PATCH request"})
-		return
-	}
-	defer response.Body.Close()
-
 	c.JSON(http.StatusOK, gin.H{"response": "Product updated"})
 }
 
 func deleteProduct(c *gin.Context) {
 	productID := c.Param("id")
-	deleteURL := credential(c).shopURL + "/services/data/v58.0/sobjects/Product2/" + productID
-
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	if err := salesforceClient(c, apiVersions.sobjects).DeleteSObject(c.Request.Context(), "Product2", productID); err != nil {
+		respondError(c, err, "Failed to delete product")
 		return
 	}
 
-	req, err := http.NewRequest("DELETE", deleteURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make DELETE request"})
-		return
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode == http.StatusNoContent {
-		c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
 //Order Routes start here:
 
 func getOrder(c *gin.Context) {
 	orderID := c.Param("id")
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/sobjects/order/" + orderID
-
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
+	result, err := salesforceClient(c, apiVersions.sobjects).GetSObject(c.Request.Context(), "Order", orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get order")
 		return
 	}
 
@@ -314,50 +240,17 @@ func createOrder(c *gin.Context) {
 	creds := credential(c)
 	accountID := c.Query("accountID")
 	checkoutID := c.Param("checkoutId")
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" + creds.webstoreId + "/checkouts/" + checkoutID + "/orders?effectiveAccountId=" + accountID
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	req, err := http.NewRequest("POST", postURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set request headers
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
 
-	// Execute the request
-	client := &http.Client{}
-	response, err := client.Do(req)
+	result, err := salesforceClient(c, apiVersions.commerce).CreateOrder(c.Request.Context(), creds.webstoreId, checkoutID, accountID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the response JSON
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+		respondError(c, err, "Failed to create order")
 		return
 	}
 
 	// Extract the `orderReferenceNumber` from the response
 	orderReferenceNumber, ok := result["orderReferenceNumber"].(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "orderReferenceNumber not found in response"})
+		c.JSON(http.StatusInternalServerError, errorBody(c, http.StatusInternalServerError, "orderReferenceNumber not found in response"))
 		return
 	}
 
@@ -370,122 +263,36 @@ func createOrder(c *gin.Context) {
 
 func updateOrder(c *gin.Context) {
 	orderID := c.Param("id")
-	updateURL := credential(c).shopURL + "/services/data/v58.0/sobjects/order/" + orderID
-
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	requestBody, ok := bindBody(c)
+	if !ok || !validBody(c, "Order", requestBody, salesforce.OpUpdate) {
 		return
 	}
 
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	req, err := http.NewRequest("PATCH", updateURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make PATCH request"})
+	if err := salesforceClient(c, apiVersions.sobjects).UpdateSObject(c.Request.Context(), "Order", orderID, requestBody); err != nil {
+		respondError(c, err, "Failed to update order")
 		return
 	}
-	defer response.Body.Close()
 
 	c.JSON(http.StatusOK, gin.H{"response": "Order updated"})
 }
+
 func deleteOrder(c *gin.Context) {
 	orderID := c.Param("id")
-	deleteURL := credential(c).shopURL + "/services/data/v58.0/sobjects/order/" + orderID
-
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	req, err := http.NewRequest("DELETE", deleteURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+	if err := salesforceClient(c, apiVersions.sobjects).DeleteSObject(c.Request.Context(), "Order", orderID); err != nil {
+		respondError(c, err, "Failed to delete order")
 		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make DELETE request"})
-		return
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode == http.StatusNoContent {
-		c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
 }
 
 //Account Routes start here:
 
 func getAccount(c *gin.Context) {
 	accountID := c.Param("id")
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/sobjects/account/" + accountID
-
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
+	result, err := salesforceClient(c, apiVersions.sobjects).GetSObject(c.Request.Context(), "Account", accountID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get account")
 		return
 	}
 
@@ -494,54 +301,17 @@ func getAccount(c *gin.Context) {
 }
 
 func createAccount(c *gin.Context) {
-	creds := credential(c)
-	postURL := creds.shopURL + "/services/data/v58.0/sobjects/account"
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	requestBody, ok := bindBody(c)
+	if !ok || !validBody(c, "Account", requestBody, salesforce.OpCreate) {
 		return
 	}
 
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	jsonData, err := json.Marshal(requestBody)
+	result, err := salesforceClient(c, apiVersions.sobjects).CreateSObject(c.Request.Context(), "Account", requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
+		respondError(c, err, "Failed to create account")
 		return
 	}
 
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
-		return
-	}
-	defer response.Body.Close()
-
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
-		return
-	}
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Account created successfully",
 		"Account Details": result,
@@ -550,125 +320,40 @@ func createAccount(c *gin.Context) {
 
 func updateAccount(c *gin.Context) {
 	accountID := c.Param("id")
-	updateURL := credential(c).shopURL + "/services/data/v58.0/sobjects/account/" + accountID
-
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
+	requestBody, ok := bindBody(c)
+	if !ok || !validBody(c, "Account", requestBody, salesforce.OpUpdate) {
 		return
 	}
 
-	req, err := http.NewRequest("PATCH", updateURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+	if err := salesforceClient(c, apiVersions.sobjects).UpdateSObject(c.Request.Context(), "Account", accountID, requestBody); err != nil {
+		respondError(c, err, "Failed to update account")
 		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make PATCH request"})
-		return
-	}
-	defer response.Body.Close()
-
 	c.JSON(http.StatusOK, gin.H{"response": "Account updated"})
 }
+
 func deleteAccount(c *gin.Context) {
 	accountID := c.Param("id")
-	deleteURL := credential(c).shopURL + "/services/data/v58.0/sobjects/account/" + accountID
-
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	if err := salesforceClient(c, apiVersions.sobjects).DeleteSObject(c.Request.Context(), "Account", accountID); err != nil {
+		respondError(c, err, "Failed to delete account")
 		return
 	}
 
-	req, err := http.NewRequest("DELETE", deleteURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make DELETE request"})
-		return
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode == http.StatusNoContent {
-		c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
-	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
 
 func getCategoryDetails(c *gin.Context) {
 	name := c.Param("name")
-
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/query?q=select%20ID%20from%20ProductCategory%20where%20name='" + name + "'"
-	//encoded_url:=url.PathEscape(getapiURL)
-
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "PostmanRuntime/7.42.0")
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
+	soql, err := salesforce.Select("Id").From("ProductCategory").Where("Name", "=", name).Build()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
 		return
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	result, err := salesforceClient(c, apiVersions.query).Query(c.Request.Context(), soql)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to query category")
 		return
 	}
 
@@ -678,50 +363,15 @@ func getCategoryDetails(c *gin.Context) {
 
 func getProductDetails(c *gin.Context) {
 	name := c.Param("name")
-
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/query?q=select%20ID%20from%20Product2%20where%20name='" + name + "'"
-	//encoded_url:=url.PathEscape(getapiURL)
-
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
+	soql, err := salesforce.Select("Id").From("Product2").Where("Name", "=", name).Build()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
 		return
 	}
 
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
+	result, err := salesforceClient(c, apiVersions.query).Query(c.Request.Context(), soql)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "PostmanRuntime/7.42.0")
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to query product")
 		return
 	}
 
@@ -731,45 +381,9 @@ func getProductDetails(c *gin.Context) {
 
 func getPayment(c *gin.Context) {
 	paymentID := c.Param("id")
-	getapiURL := credential(c).shopURL + "/services/data/v58.0/sobjects/payment/" + paymentID
-
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
+	result, err := salesforceClient(c, apiVersions.sobjects).GetSObject(c.Request.Context(), "Payment", paymentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get payment")
 		return
 	}
 
@@ -778,205 +392,63 @@ func getPayment(c *gin.Context) {
 }
 
 func createCart(c *gin.Context) {
-	creds := credential(c)
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" + creds.webstoreId + "/carts"
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
 	// Parse the request body
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	// Marshal the request body into JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	// Create the HTTP request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Make the HTTP request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	creds := credential(c)
+	result, _, err := salesforceClient(c, apiVersions.commerce).CreateCart(c.Request.Context(), creds.webstoreId, requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-	// Parse the response
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
-		return
-	}
-
-	// Check for errors in the response
-	if response.StatusCode != http.StatusCreated {
-		c.JSON(response.StatusCode, gin.H{"error": "Failed to create cart", "details": result})
+		respondError(c, err, "Failed to create cart")
 		return
 	}
 
 	// Extract and return the cart ID
 	cartID, ok := result["cartId"].(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cart ID not found in response"})
+		c.JSON(http.StatusInternalServerError, errorBody(c, http.StatusInternalServerError, "Cart ID not found in response"))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"cartID": cartID})
 }
+
 func addItemstoCart(c *gin.Context) {
-	// Load credentials and URL components
 	creds := credential(c)
 	cartID := c.Param("cartId")
 	accountID := c.Query("accountID")
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" + creds.webstoreId + "/carts/" + cartID + "/cart-items?effectiveAccountId=" + accountID
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
 
 	// Parse the request body
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	// Marshal the request body into JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	// Create the HTTP request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Make the HTTP request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the response
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
 
-	// Check for errors in the response
-	if response.StatusCode != http.StatusCreated {
-		c.JSON(response.StatusCode, gin.H{"error": "Failed to add item to cart", "details": result})
+	if _, err := salesforceClient(c, apiVersions.commerce).AddCartItem(c.Request.Context(), creds.webstoreId, cartID, accountID, requestBody); err != nil {
+		respondError(c, err, "Failed to add item to cart")
 		return
 	}
 
 	// Return success message
 	c.JSON(http.StatusCreated, gin.H{"message": "Product successfully added to cart"})
 }
+
 func createDeliveryGroup(c *gin.Context) {
-	// Load credentials
 	creds := credential(c)
-	cartID := c.Param("cartid")
+	cartID := c.Param("cartId")
 	accountID := c.Query("accountID")
 
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" +
-		creds.webstoreId + "/carts/" + cartID +
-		"/delivery-groups?effectiveAccountId=" + accountID
-
-	// Retrieve the access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
 	// Parse JSON payload from the client
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	// Marshal the request body into JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	// Create the POST request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set required headers
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the HTTP request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	result, err := salesforceClient(c, apiVersions.commerce).CreateDeliveryGroup(c.Request.Context(), creds.webstoreId, cartID, accountID, requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the response JSON
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+		respondError(c, err, "Failed to create delivery group")
 		return
 	}
 
@@ -988,144 +460,45 @@ func createDeliveryGroup(c *gin.Context) {
 }
 
 func createCheckout(c *gin.Context) {
-	// Load credentials
 	creds := credential(c)
 	accountID := c.Query("accountID")
 
-	// Construct the request URL
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" +
-		creds.webstoreId + "/checkouts?effectiveAccountId=" + accountID
-	// Retrieve the access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
 	// Parse the request body
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	// Marshal the request body into JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	// Create the POST request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set required headers
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the HTTP request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	client := salesforceClient(c, apiVersions.commerce)
+	result, err := client.CreateCheckout(c.Request.Context(), creds.webstoreId, accountID, requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-	// Parse the response JSON
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+		respondError(c, err, "Failed to create checkout")
 		return
 	}
 
-	// Extract the checkoutID
-	checkoutID, ok := result["checkoutId"].(string)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "checkoutID not found in response"})
-		return
+	// Checkouts are calculated asynchronously; a 202 may not carry the ID yet
+	checkoutID, _ := result["checkoutId"].(string)
+	if checkoutID == "" {
+		checkoutID = salesforce.ActiveCheckout
 	}
-
-	// Return the checkoutID
-	c.JSON(http.StatusOK, gin.H{
-		"message":    "Checkout created successfully",
-		"checkoutID": checkoutID,
-	})
+	checkout, err := waitForCheckout(c, client, checkoutID, accountID)
+	respondCheckout(c, checkout, err, "Checkout created successfully")
 }
 
 func createPayment(c *gin.Context) {
-	// Load credentials
-
 	creds := credential(c)
 	checkoutID := c.Param("checkoutId")
 	accountID := c.Query("accountID")
 
-	// Construct the request URL
-	postURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" +
-		creds.webstoreId + "/checkouts/" + checkoutID + "/payments?effectiveAccountId=" +
-		accountID
-	// Retrieve the access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
 	// Parse the request body
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	// Marshal the request body into JSON
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	// Create the POST request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set required headers
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the HTTP request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+	requestBody, ok := bindBody(c)
+	if !ok {
 		return
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	result, err := salesforceClient(c, apiVersions.commerce).CreatePayment(c.Request.Context(), creds.webstoreId, checkoutID, accountID, requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-
-	// Parse the response JSON
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+		respondError(c, err, "Failed to create payment")
 		return
 	}
 
@@ -1135,157 +508,62 @@ func createPayment(c *gin.Context) {
 		"paymentDetails": result,
 	})
 }
+
 func getOrderSummary(c *gin.Context) {
 	accountID := c.Query("accountID")
 	pageToken := c.Query("pageToken")
 	pageSize := c.Query("pageSize")
-	var queryParameter string
-	if(accountID=="null"){
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Account Id required"})
-	}
-	if(pageSize!="null" ){
-		queryParameter+="pageSize="+pageSize+"&"
-	}
-	if(pageToken!="null"){
-		queryParameter+="pageToken="+pageToken
-	}
-	creds := credential(c)
-	getapiURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/" + creds.webstoreId + "/order-summaries?effectiveAccountId=" + accountID + "&ownerScoped=false&fields=AccountId&includeProducts=true&"+queryParameter
-	// Get the dynamic access token
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
+	if accountID == "null" {
+		c.JSON(http.StatusInternalServerError, errorBody(c, http.StatusInternalServerError, "Account Id required"))
 		return
 	}
 
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
+	params := url.Values{}
+	params.Set("ownerScoped", "false")
+	params.Set("fields", "AccountId")
+	params.Set("includeProducts", "true")
+	if pageSize != "null" {
+		params.Set("pageSize", pageSize)
 	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
+	if pageToken != "null" {
+		params.Set("pageToken", pageToken)
 	}
-	defer response.Body.Close()
 
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
+	creds := credential(c)
+	result, err := salesforceClient(c, apiVersions.commerce).GetOrderSummaries(c.Request.Context(), creds.webstoreId, accountID, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get order summaries")
 		return
 	}
 
 	// Return the parsed JSON result
 	c.JSON(http.StatusOK, result)
 }
-func createCategory(c *gin.Context) {
-	creds := credential(c)
-	postURL := creds.shopURL + "/services/data/v58.0/sobjects/ProductCategory"
-	accessToken, err := getAccessToken(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	var requestBody map[string]interface{}
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
-		return
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal JSON"})
-		return
-	}
-
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make POST request"})
+func createCategory(c *gin.Context) {
+	requestBody, ok := bindBody(c)
+	if !ok || !validBody(c, "ProductCategory", requestBody, salesforce.OpCreate) {
 		return
 	}
-	defer response.Body.Close()
 
-	body, err := ioutil.ReadAll(response.Body)
+	result, err := salesforceClient(c, apiVersions.sobjects).CreateSObject(c.Request.Context(), "ProductCategory", requestBody)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
+		respondError(c, err, "Failed to create category")
 		return
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
-		return
-	}
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Category created successfully",
 		"Account Details": result,
 	})
 }
+
 func getProductsList(c *gin.Context) {
 	creds := credential(c)
-	ids:=c.Query("ids")
-	getapiURL := creds.shopURL + "/services/data/v62.0/commerce/webstores/"+creds.webstoreId+"/products?ids="+ids
-	accessToken, err := getAccessToken(c)
+	ids := c.Query("ids")
+	result, err := salesforceClient(c, apiVersions.commerce).GetProducts(c.Request.Context(), creds.webstoreId, ids)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get access token"})
-		return
-	}
-
-	// Create a new request with the API URL
-	req, err := http.NewRequest("GET", getapiURL, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-
-	// Set the Authorization header with the dynamic access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the API request
-	client := &http.Client{}
-	response, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to make API request"})
-		return
-	}
-	defer response.Body.Close()
-
-	// Read the response body
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response body"})
-		return
-	}
-	// Parse the JSON response
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		respondError(c, err, "Failed to get products")
 		return
 	}
 
@@ -1295,9 +573,28 @@ func getProductsList(c *gin.Context) {
 
 func main() {
 	godotenv.Load(".env")
+	accessTokens = salesforce.NewTokenCache(envDuration("CONNECTOR_TOKEN_TTL", defaultTokenTTL))
+
+	var err error
+	if defaultAllowlist, err = loadDefaultAllowlist(); err != nil {
+		log.Fatal(err)
+	}
+	if defaultExternalIDs, err = loadDefaultExternalIDs(); err != nil {
+		log.Fatal(err)
+	}
+	if tenants, err = loadTenants(); err != nil {
+		log.Fatal("Failed to load tenant profiles: ", err)
+	}
+	if apiVersions, err = loadAPIVersions(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkAPIVersions(apiVersions, tenants); err != nil {
+		log.Fatal(err)
+	}
 	router := gin.Default()
 
 	router.Use(cors.Default())
+	router.Use(correlationID())
 
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -1305,44 +602,84 @@ func main() {
 		})
 	})
 
+	// every Salesforce route needs a resolved, validated tenant
+	api := router.Group("/")
+	api.Use(requireTenant())
 
 	//product routes
-	router.GET("/getProductDetailsbyId/:id", getProduct)
-	router.POST("/createProduct", createProduct)
-	router.PATCH("/updateProductbyId/:id", updateProduct)
-	router.DELETE("/deleteProductbyId/:id", deleteProduct)
+	api.GET("/getProductDetailsbyId/:id", getProduct)
+	api.POST("/createProduct", createProduct)
+	api.PATCH("/updateProductbyId/:id", updateProduct)
+	api.DELETE("/deleteProductbyId/:id", deleteProduct)
+	api.PATCH("/upsertProductbyExternalId/:externalId", upsertByExternalID("Product2", "Product"))
+	api.POST("/import/products", importProducts)
 
 	//order routes
-	router.GET("/getOrderDetailsbyId/:id", getOrder)
-	router.POST("/createOrder/:checkoutId", createOrder)
-	router.PATCH("/updateOrderbyId/:id", updateOrder)
-	router.DELETE("/deleteOrderbyId/:id", deleteOrder)
-	router.GET("/getOrderSummary", getOrderSummary)
+	api.GET("/getOrderDetailsbyId/:id", getOrder)
+	api.POST("/createOrder/:checkoutId", createOrder)
+	api.PATCH("/updateOrderbyId/:id", updateOrder)
+	api.DELETE("/deleteOrderbyId/:id", deleteOrder)
+	api.GET("/getOrderSummary", getOrderSummary)
+	api.POST("/orders/place", placeOrder)
 
 	//account routes
-	router.GET("/getAccountDetailsbyId/:id", getAccount)
-	router.POST("/createAccount", createAccount)
-	router.PATCH("/updateAccountbyId/:id", updateAccount)
-	router.DELETE("/deleteAccountbyId/:id", deleteAccount)
+	api.GET("/getAccountDetailsbyId/:id", getAccount)
+	api.POST("/createAccount", createAccount)
+	api.PATCH("/updateAccountbyId/:id", updateAccount)
+	api.DELETE("/deleteAccountbyId/:id", deleteAccount)
+	api.PATCH("/upsertAccountbyExternalId/:externalId", upsertByExternalID("Account", "Account"))
 
 	//getCategoryId from Name
-	router.GET("/getCategoryDetailsbyName/:name", getCategoryDetails)
-	router.GET("/getProductDetailsbyName/:name", getProductDetails)
+	api.GET("/getCategoryDetailsbyName/:name", getCategoryDetails)
+	api.GET("/getProductDetailsbyName/:name", getProductDetails)
+	api.GET("/query", runQuery)
+	api.GET("/export", runExport)
+
+	//generic sObject routes, limited to each tenant's allowlist
+	api.GET("/sobjects/:type/:id", getSObject)
+	api.POST("/sobjects/:type", createSObject)
+	api.PATCH("/sobjects/:type/:id", updateSObject)
+	api.DELETE("/sobjects/:type/:id", deleteSObject)
+	api.GET("/metadata/:type", getMetadata)
+	api.POST("/composite", runComposite)
+
+	//sObject Collections, chunked into calls of 200 records
+	api.POST("/collections/:type", createRecords)
+	api.PATCH("/collections/:type", updateRecords)
+	api.DELETE("/collections/:type", deleteRecords)
+
+	//Bulk API 2.0 ingest jobs, tracked locally
+	api.POST("/jobs/ingest/:type", createIngestJob)
+	api.GET("/jobs/:id", getJob)
+	api.GET("/jobs/:id/results/:kind", getJobResults)
 
 	//getPayment
-	router.GET("/getPayment/:id", getPayment)
+	api.GET("/getPayment/:id", getPayment)
 
 	//craeteCart
-	router.POST("/createCart", createCart)
-	router.POST("/addItemstoCart/:cartId", addItemstoCart)
-	router.POST("addDeliveryGroup/:cartId", createDeliveryGroup)
+	api.POST("/createCart", createCart)
+	api.POST("/addItemstoCart/:cartId", addItemstoCart)
+	api.GET("/getCart/:cartId", getCart)
+	api.PATCH("/updateCartItem/:cartId/:itemId", updateCartItem)
+	api.DELETE("/removeCartItem/:cartId/:itemId", removeCartItem)
+	api.DELETE("/deleteCart/:cartId", deleteCart)
+	api.POST("/applyCoupon/:cartId", applyCoupon)
+	api.GET("/getCartCoupons/:cartId", getCartCoupons)
+	api.DELETE("/removeCoupon/:cartId/:couponId", removeCoupon)
+	api.GET("/getCartPromotions/:cartId", getCartPromotions)
+	api.POST("addDeliveryGroup/:cartId", createDeliveryGroup)
 	//checkoutandpayment
-	router.POST("/checkout", createCheckout)
-	router.POST("/setPaymentMethod/:checkoutId", createPayment)
+	api.POST("/checkout", createCheckout)
+	api.GET("/checkout/:id", getCheckoutStatus)
+	api.GET("/checkout/:id/deliveryMethods", getDeliveryMethods)
+	api.PATCH("/checkout/:id/deliveryMethod", selectDeliveryMethod)
+	api.PATCH("/checkout/:id/shippingAddress", updateShippingAddress)
+	api.POST("/setPaymentMethod/:checkoutId", createPayment)
 
 	//additional
-	router.POST("createProductCategory",createCategory)
-	router.GET("listProductsbypassingIds",getProductsList)
+	api.POST("createProductCategory", createCategory)
+	api.PATCH("/upsertProductCategorybyExternalId/:externalId", upsertByExternalID("ProductCategory", "Category"))
+	api.GET("listProductsbypassingIds", getProductsList)
 
 	port := os.Getenv("CONNECTOR_ENV_PORT")
 	if port == "" {
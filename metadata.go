@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldMetadata is what a form needs to know about a field; the full
+// describe is several hundred kilobytes for most objects.
+type fieldMetadata struct {
+	Name           string              `json:"name"`
+	Label          string              `json:"label"`
+	Type           string              `json:"type"`
+	Length         int                 `json:"length,omitempty"`
+	Required       bool                `json:"required"`
+	Createable     bool                `json:"createable"`
+	Updateable     bool                `json:"updateable"`
+	Nillable       bool                `json:"nillable"`
+	ExternalID     bool                `json:"externalId"`
+	ReferenceTo    []string            `json:"referenceTo,omitempty"`
+	PicklistValues []picklistValueInfo `json:"picklistValues,omitempty"`
+	// Restricted picklists only accept the listed values
+	Restricted bool `json:"restrictedPicklist,omitempty"`
+}
+
+type picklistValueInfo struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// getMetadata answers GET /metadata/:type with a trimmed describe of an
+// allowed object, served from the describe cache. Tenants limited to some
+// fields of the object only see those.
+func getMetadata(c *gin.Context) {
+	sobjectType := c.Param("type")
+	if !checkSObjectAccess(c, sobjectType, nil) {
+		return
+	}
+
+	cred := credential(c)
+	describe, err := describes.get(c.Request.Context(), cred, salesforceClient(c, apiVersions.sobjects), sobjectType)
+	if err != nil {
+		respondError(c, err, "Failed to describe "+sobjectType)
+		return
+	}
+
+	allowed := cred.sobjects.fields(sobjectType)
+	fields := []fieldMetadata{}
+	for i := range describe.Fields {
+		f := &describe.Fields[i]
+		if allowed != nil && !containsFold(allowed, f.Name) {
+			continue
+		}
+		field := fieldMetadata{
+			Name:        f.Name,
+			Label:       f.Label,
+			Type:        f.Type,
+			Length:      f.Length,
+			Required:    f.Required(),
+			Createable:  f.Createable,
+			Updateable:  f.Updateable,
+			Nillable:    f.Nillable,
+			ExternalID:  f.ExternalID,
+			ReferenceTo: f.ReferenceTo,
+			Restricted:  f.RestrictedPicklist,
+		}
+		for _, p := range f.PicklistValues {
+			if p.Active {
+				field.PicklistValues = append(field.PicklistValues, picklistValueInfo{Value: p.Value, Label: p.Label})
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":       describe.Name,
+		"label":      describe.Label,
+		"createable": describe.Createable,
+		"updateable": describe.Updateable,
+		"deletable":  describe.Deletable,
+		"fields":     fields,
+	})
+}
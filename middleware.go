@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	credentialsKey   = "credentials"
+	correlationIDKey = "correlationId"
+
+	correlationIDHeader = "X-Correlation-ID"
+)
+
+// Hosts a shopUrl may point at. Anything else is refused so the connector
+// can't be used to send requests (and client secrets) to arbitrary servers.
+// Override with a comma-separated CONNECTOR_ALLOWED_SHOP_DOMAINS.
+var defaultShopDomains = []string{"salesforce.com", "force.com", "my.site.com"}
+
+// correlationID tags every request with the caller's X-Correlation-ID, or a
+// fresh one, and echoes it back so errors can be matched to log lines.
+func correlationID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(correlationIDHeader)
+		if id == "" {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		c.Set(correlationIDKey, id)
+		c.Header(correlationIDHeader, id)
+		c.Next()
+	}
+}
+
+// requireTenant resolves the caller's Salesforce credentials once per request,
+// rejects incomplete or unsafe ones, and stores them for credential().
+func requireTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cred, err := resolveCredentials(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorBody(c, http.StatusUnauthorized, err.Error()))
+			return
+		}
+
+		if missing := missingCredentialFields(cred); len(missing) > 0 {
+			if cred.tenantID != "" {
+				body := errorBody(c, http.StatusInternalServerError, "Tenant profile is incomplete")
+				body["missing"] = missing
+				c.AbortWithStatusJSON(http.StatusInternalServerError, body)
+			} else {
+				body := errorBody(c, http.StatusBadRequest, "Missing required credential headers")
+				body["missing"] = missing
+				c.AbortWithStatusJSON(http.StatusBadRequest, body)
+			}
+			return
+		}
+
+		if err := validateShopURL(cred.shopURL); err != nil {
+			body := errorBody(c, http.StatusBadRequest, "Invalid shopUrl")
+			body["details"] = err.Error()
+			c.AbortWithStatusJSON(http.StatusBadRequest, body)
+			return
+		}
+
+		c.Set(credentialsKey, cred)
+		c.Next()
+	}
+}
+
+// missingCredentialFields names, by header, every credential the token flow needs but didn't get.
+func missingCredentialFields(cred credentials) []string {
+	missing := []string{}
+	if cred.shopURL == "" {
+		missing = append(missing, "shopUrl")
+	}
+	if cred.clientId == "" {
+		missing = append(missing, "clientId")
+	}
+	if cred.grantType == grantTypeJWTBearer {
+		if cred.jwtSubject == "" {
+			missing = append(missing, "jwtSubject")
+		}
+	} else if cred.clientSecret == "" {
+		missing = append(missing, "clientSecret")
+	}
+	if cred.webstoreId == "" {
+		missing = append(missing, "webstoreId")
+	}
+	return missing
+}
+
+// validateShopURL accepts only a bare https origin on a Salesforce domain.
+func validateShopURL(shopURL string) error {
+	u, err := url.Parse(shopURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return errors.New("shopUrl must use https")
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" || (u.Path != "" && u.Path != "/") {
+		return errors.New("shopUrl must be a bare origin such as https://example.my.salesforce.com")
+	}
+	if port := u.Port(); port != "" && port != "443" {
+		return errors.New("shopUrl must not use a custom port")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range allowedShopDomains() {
+		if strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a Salesforce domain", host)
+}
+
+func allowedShopDomains() []string {
+	env := os.Getenv("CONNECTOR_ALLOWED_SHOP_DOMAINS")
+	if env == "" {
+		return defaultShopDomains
+	}
+	var domains []string
+	for _, d := range strings.Split(env, ",") {
+		if d = strings.Trim(strings.TrimSpace(strings.ToLower(d)), "."); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long cleanup of a failed order placement may take, whether or not the
+// caller is still waiting.
+const compensationTimeout = 30 * time.Second
+
+// orderRequest is the body of POST /orders/place. Cart, DeliveryGroup,
+// Checkout and Payment are passed on as the bodies of createCart,
+// addDeliveryGroup, checkout and setPaymentMethod. Without AccountID the
+// order is a guest order, and ContactInfo (email, firstName, lastName,
+// phoneNumber) is required to reach the buyer.
+type orderRequest struct {
+	AccountID        string                   `json:"accountId"`
+	ContactInfo      map[string]interface{}   `json:"contactInfo"`
+	Cart             map[string]interface{}   `json:"cart"`
+	Items            []map[string]interface{} `json:"items"`
+	DeliveryGroup    map[string]interface{}   `json:"deliveryGroup"`
+	DeliveryMethodID string                   `json:"deliveryMethodId"`
+	Checkout         map[string]interface{}   `json:"checkout"`
+	Payment          map[string]interface{}   `json:"payment"`
+}
+
+// compensation is one cleanup step run after a failed placement.
+type compensation struct {
+	Step    string `json:"step"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// placeOrder answers POST /orders/place: cart, items, delivery group,
+// checkout, delivery method, payment and order in one call, with a single
+// client and token. It runs as a saga: when a step fails, the checkout and
+// cart created so far are deleted again and the answer names the failed
+// step. A payment that was already authorized is not reversed, and when
+// creating the order itself fails without a clear rejection (4xx) the cart and
+// checkout are kept and returned, as the order may exist.
+//
+// Only a cart the saga created is ever deleted. When the buyer already has an
+// active cart, Salesforce hands that back instead of a new one; the order is
+// then refused with 409 and the buyer's cart left alone.
+//
+// Buyer orders are placed on behalf of accountId. Guest orders leave it out
+// and are placed as the tenant's Salesforce user, which must be the store's
+// guest user for them to work; their contactInfo is set on the checkout.
+func placeOrder(c *gin.Context) {
+	var order orderRequest
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid JSON"))
+		return
+	}
+	if len(order.Items) == 0 || order.Payment == nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "items and payment are required"))
+		return
+	}
+	if order.AccountID == "" && order.ContactInfo["email"] == nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Guest orders need contactInfo with an email"))
+		return
+	}
+
+	creds := credential(c)
+	client := salesforceClient(c, apiVersions.commerce)
+	ctx := c.Request.Context()
+	var cartID, checkoutID string
+
+	fail := func(step string, err error) {
+		log.Printf("[%s] placing order failed at %s: %v", c.GetString(correlationIDKey), step, err)
+		status, body := errorResponse(c, err, "Failed to place order")
+		var calculation *checkoutCalculationError
+		if errors.As(err, &calculation) {
+			status = http.StatusUnprocessableEntity
+			body["status"] = status
+			body["calculationErrors"] = calculation.errors
+		}
+		body["failedStep"] = step
+		body["compensation"] = compensateOrder(ctx, client, creds.webstoreId, order.AccountID, cartID, checkoutID)
+		c.JSON(status, body)
+	}
+
+	if order.Cart == nil {
+		order.Cart = map[string]interface{}{}
+	}
+	if order.Cart["effectiveAccountId"] == nil && order.AccountID != "" {
+		order.Cart["effectiveAccountId"] = order.AccountID
+	}
+	cart, created, err := client.CreateCart(ctx, creds.webstoreId, order.Cart)
+	if err != nil {
+		fail("createCart", err)
+		return
+	}
+	if !created {
+		// The buyer's own cart, with whatever they put in it; not ours to fill or delete
+		body := errorBody(c, http.StatusConflict, "The account already has an active cart")
+		body["failedStep"] = "createCart"
+		body["activeCartId"] = cart["cartId"]
+		c.JSON(http.StatusConflict, body)
+		return
+	}
+	if cartID, _ = cart["cartId"].(string); cartID == "" {
+		fail("createCart", errors.New("cart ID not found in response"))
+		return
+	}
+
+	for _, item := range order.Items {
+		if item["type"] == nil {
+			item["type"] = "Product"
+		}
+		if _, err := client.AddCartItem(ctx, creds.webstoreId, cartID, order.AccountID, item); err != nil {
+			fail("addItemstoCart", err)
+			return
+		}
+	}
+
+	if order.DeliveryGroup != nil {
+		if _, err := client.CreateDeliveryGroup(ctx, creds.webstoreId, cartID, order.AccountID, order.DeliveryGroup); err != nil {
+			fail("addDeliveryGroup", err)
+			return
+		}
+	}
+
+	if order.Checkout == nil {
+		order.Checkout = map[string]interface{}{}
+	}
+	order.Checkout["cartId"] = cartID
+	started, err := client.CreateCheckout(ctx, creds.webstoreId, order.AccountID, order.Checkout)
+	if err != nil {
+		fail("checkout", err)
+		return
+	}
+	checkoutID, _ = started["checkoutId"].(string)
+	if checkoutID == "" {
+		checkoutID = salesforce.ActiveCheckout
+	}
+	checkout, err := readyCheckout(c, client, checkoutID, order.AccountID)
+	if checkout != nil && checkout.ID() != "" {
+		checkoutID = checkout.ID()
+	}
+	if err != nil {
+		fail("checkout", err)
+		return
+	}
+
+	if order.ContactInfo != nil {
+		changes := map[string]interface{}{"contactInfo": order.ContactInfo}
+		if _, err := client.UpdateCheckout(ctx, creds.webstoreId, checkoutID, order.AccountID, changes); err != nil {
+			fail("setContactInfo", err)
+			return
+		}
+	}
+
+	if order.DeliveryMethodID != "" {
+		changes := map[string]interface{}{"deliveryMethodId": order.DeliveryMethodID}
+		if _, err := client.UpdateCheckout(ctx, creds.webstoreId, checkoutID, order.AccountID, changes); err != nil {
+			fail("selectDeliveryMethod", err)
+			return
+		}
+		if _, err := readyCheckout(c, client, checkoutID, order.AccountID); err != nil {
+			fail("selectDeliveryMethod", err)
+			return
+		}
+	}
+
+	if _, err := client.CreatePayment(ctx, creds.webstoreId, checkoutID, order.AccountID, order.Payment); err != nil {
+		fail("setPaymentMethod", err)
+		return
+	}
+
+	// After a lost connection, a timeout or a 5xx the order may well have been
+	// placed, so nothing is undone; the caller checks the checkout
+	unknownOutcome := func(status int, body gin.H) {
+		body["failedStep"] = "createOrder"
+		body["cartId"] = cartID
+		body["checkoutId"] = checkoutID
+		c.JSON(status, body)
+	}
+	placed, err := client.CreateOrder(ctx, creds.webstoreId, checkoutID, order.AccountID)
+	if err != nil {
+		var apiErr *salesforce.APIError
+		var tokenErr *salesforce.TokenError
+		if errors.As(err, &tokenErr) || (errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500) {
+			// never sent, or turned down: there is no order
+			fail("createOrder", err)
+			return
+		}
+		log.Printf("[%s] placing order failed at createOrder: %v", c.GetString(correlationIDKey), err)
+		unknownOutcome(errorResponse(c, err, "Order placement outcome unknown"))
+		return
+	}
+	orderReferenceNumber, _ := placed["orderReferenceNumber"].(string)
+	if orderReferenceNumber == "" {
+		body := errorBody(c, http.StatusInternalServerError, "orderReferenceNumber not found in response")
+		unknownOutcome(http.StatusInternalServerError, body)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":              "Order placed successfully",
+		"orderReferenceNumber": orderReferenceNumber,
+		"cartId":               cartID,
+		"checkoutId":           checkoutID,
+	})
+}
+
+// checkoutCalculationError is a checkout that finished calculating with errors.
+type checkoutCalculationError struct {
+	errors []interface{}
+}
+
+func (e *checkoutCalculationError) Error() string {
+	return "checkout calculation failed"
+}
+
+// readyCheckout waits for the checkout and turns a timeout or calculation
+// errors into an error, for steps that can't go on without a priced checkout.
+func readyCheckout(c *gin.Context, client *salesforce.Client, checkoutID, accountID string) (*salesforce.Checkout, error) {
+	checkout, err := waitForCheckout(c, client, checkoutID, accountID)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return checkout, errors.New("checkout still calculating after " + envDuration("CONNECTOR_CHECKOUT_TIMEOUT", defaultCheckoutTimeout).String())
+	case err != nil:
+		return checkout, err
+	case len(checkout.Errors()) > 0:
+		return checkout, &checkoutCalculationError{errors: checkout.Errors()}
+	}
+	return checkout, nil
+}
+
+// compensateOrder undoes the steps of a failed placement that left something
+// behind: the checkout first, then the cart it was started from. It runs
+// even if the caller has gone away.
+func compensateOrder(ctx context.Context, client *salesforce.Client, webstoreID, accountID, cartID, checkoutID string) []compensation {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+
+	steps := []compensation{}
+	if checkoutID != "" {
+		err := client.DeleteCheckout(ctx, webstoreID, checkoutID, accountID)
+		steps = append(steps, compensationResult("deleteCheckout", err))
+	}
+	if cartID != "" {
+		err := client.DeleteCart(ctx, webstoreID, cartID, accountID)
+		steps = append(steps, compensationResult("deleteCart", err))
+	}
+	return steps
+}
+
+func compensationResult(step string, err error) compensation {
+	if err != nil {
+		log.Printf("order compensation %s failed: %v", step, err)
+		return compensation{Step: step, Error: err.Error()}
+	}
+	return compensation{Step: step, Success: true}
+}
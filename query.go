@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many records GET /query?all=true collects before handing back a cursor
+// instead. Override with CONNECTOR_QUERY_MAX_RECORDS.
+const defaultQueryMaxRecords = 10000
+
+var filterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "!=",
+	"lt":   "<",
+	"lte":  "<=",
+	"gt":   ">",
+	"gte":  ">=",
+	"like": "LIKE",
+	"in":   "IN",
+	"nin":  "NOT IN",
+}
+
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// queryLocatorPattern matches the id at the end of a nextRecordsUrl, e.g. 01gD0000002HU6KIAW-2000
+var queryLocatorPattern = regexp.MustCompile(`^[A-Za-z0-9]+-[0-9]+$`)
+
+// runQuery answers GET /query. The statement is assembled from structured
+// parameters with the SOQL builder, never from caller-supplied SOQL:
+//
+//	sobject=Product2            object to query (required)
+//	fields=Id,Name              fields to select (default Id)
+//	filter=Field:op:value       repeatable; op is eq, ne, lt, lte, gt, gte, like, in or nin
+//	order=Name,-CreatedDate     sort fields, "-" for descending
+//	limit=100
+//	all=true                    follow nextRecordsUrl up to CONNECTOR_QUERY_MAX_RECORDS
+//	cursor=...                  continue a previous result instead of starting a new query
+//
+// Filter values are typed: null, true/false, numbers, RFC 3339 datetimes,
+// YYYY-MM-DD dates and date literals such as TODAY or LAST_N_DAYS:30 are sent
+// as such, anything else as a string. Wrap a value in single quotes
+// to force a string, and separate in/nin values with "|".
+func runQuery(c *gin.Context) {
+	client := salesforceClient(c, apiVersions.query)
+	ctx := c.Request.Context()
+
+	var result *salesforce.QueryResult
+	if cursor := c.Query("cursor"); cursor != "" {
+		locator, err := decodeQueryCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, "Invalid cursor"))
+			return
+		}
+		if result, err = client.QueryMore(ctx, "/query/"+locator); err != nil {
+			respondError(c, err, "Failed to continue query")
+			return
+		}
+	} else {
+		soql, sobjectType, fields, err := buildQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorBody(c, http.StatusBadRequest, err.Error()))
+			return
+		}
+		if !checkSObjectAccess(c, sobjectType, fields) {
+			return
+		}
+		if result, err = client.Query(ctx, soql); err != nil {
+			respondError(c, err, "Failed to run query")
+			return
+		}
+	}
+
+	if c.Query("all") == "true" {
+		maxRecords := envInt("CONNECTOR_QUERY_MAX_RECORDS", defaultQueryMaxRecords)
+		for !result.Done && len(result.Records) < maxRecords {
+			next, err := client.QueryMore(ctx, result.NextRecordsURL)
+			if err != nil {
+				respondError(c, err, "Failed to fetch query results")
+				return
+			}
+			result.Records = append(result.Records, next.Records...)
+			result.Done = next.Done
+			result.NextRecordsURL = next.NextRecordsURL
+		}
+	}
+
+	response := gin.H{
+		"totalSize": result.TotalSize,
+		"done":      result.Done,
+		"records":   result.Records,
+	}
+	if !result.Done {
+		response["cursor"] = encodeQueryCursor(result.NextRecordsURL)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// buildQuery returns the SOQL for the request along with the object and every
+// field it selects, filters or sorts on, for the allowlist check.
+func buildQuery(c *gin.Context) (soql, sobjectType string, fields []string, err error) {
+	sobjectType = c.Query("sobject")
+	if sobjectType == "" {
+		return "", "", nil, fmt.Errorf("sobject is required")
+	}
+	selected := splitList(c.DefaultQuery("fields", "Id"), ",")
+	fields = append(fields, selected...)
+	q := salesforce.Select(selected...).From(sobjectType)
+
+	for _, filter := range c.QueryArray("filter") {
+		parts := strings.SplitN(filter, ":", 3)
+		if len(parts) != 3 {
+			return "", "", nil, fmt.Errorf("filter %q should look like Field:op:value", filter)
+		}
+		operator, ok := filterOperators[strings.ToLower(parts[1])]
+		if !ok {
+			return "", "", nil, fmt.Errorf("filter %q has an unknown operator", filter)
+		}
+		var value interface{}
+		if operator == "IN" || operator == "NOT IN" {
+			var values []interface{}
+			for _, v := range strings.Split(parts[2], "|") {
+				values = append(values, filterValue(v))
+			}
+			value = values
+		} else {
+			value = filterValue(parts[2])
+		}
+		q.Where(parts[0], operator, value)
+		fields = append(fields, parts[0])
+	}
+
+	for _, field := range splitList(c.Query("order"), ",") {
+		name := strings.TrimPrefix(field, "-")
+		q.OrderBy(name, name != field)
+		fields = append(fields, name)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return "", "", nil, fmt.Errorf("limit must be a positive number")
+		}
+		q.Limit(n)
+	}
+	soql, err = q.Build()
+	return soql, sobjectType, fields, err
+}
+
+// filterValue turns a filter's text into the Go value the SOQL builder renders.
+func filterValue(v string) interface{} {
+	if len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
+		return v[1 : len(v)-1]
+	}
+	switch v {
+	case "null":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if numberPattern.MatchString(v) {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return salesforce.Date(t)
+	}
+	if literal := salesforce.DateLiteral(strings.ToUpper(v)); literal.Valid() {
+		return literal
+	}
+	return v
+}
+
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// A cursor only carries the query locator, so callers can't use it to reach
+// any other Salesforce URL.
+func encodeQueryCursor(nextRecordsURL string) string {
+	locator := nextRecordsURL[strings.LastIndex(nextRecordsURL, "/")+1:]
+	return base64.RawURLEncoding.EncodeToString([]byte(locator))
+}
+
+func decodeQueryCursor(cursor string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	if !queryLocatorPattern.Match(data) {
+		return "", fmt.Errorf("not a query locator")
+	}
+	return string(data), nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQueryCursor(t *testing.T) {
+	forged := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name        string
+		cursor      string
+		wantLocator string
+		wantErr     bool
+	}{
+		{name: "round trip", cursor: encodeQueryCursor("/services/data/v58.0/query/01gD0000002HU6KIAW-2000"), wantLocator: "01gD0000002HU6KIAW-2000"},
+		{name: "other path", cursor: forged("../sobjects/User/005xx"), wantErr: true},
+		{name: "full url", cursor: forged("https://evil.example/01gD0000002HU6KIAW-2000"), wantErr: true},
+		{name: "extra query", cursor: forged("01gD0000002HU6KIAW-2000?q=SELECT+Id+FROM+User"), wantErr: true},
+		{name: "locator then path", cursor: forged("01gD0000002HU6KIAW-2000/../../sobjects"), wantErr: true},
+		{name: "no batch number", cursor: forged("01gD0000002HU6KIAW"), wantErr: true},
+		{name: "not base64", cursor: "01gD0000002HU6KIAW-2000!", wantErr: true},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte("01g-2000")), wantErr: true},
+		{name: "empty", cursor: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeQueryCursor(tt.cursor)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("decodeQueryCursor(%q) = %q, want an error", tt.cursor, got)
+				}
+				return
+			}
+			if err != nil || got != tt.wantLocator {
+				t.Errorf("decodeQueryCursor(%q) = %q, %v, want %q", tt.cursor, got, err, tt.wantLocator)
+			}
+		})
+	}
+}
+
+func TestFilterValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{value: "Shoes", want: "Shoes"},
+		{value: "'42'", want: "42"},
+		{value: "'null'", want: "null"},
+		{value: "''", want: ""},
+		{value: "null", want: nil},
+		{value: "true", want: true},
+		{value: "false", want: false},
+		{value: "42", want: float64(42)},
+		{value: "-10.5", want: -10.5},
+		{value: "1e3", want: "1e3"},
+		{value: "NaN", want: "NaN"},
+		{value: "Inf", want: "Inf"},
+		{value: "2024-01-31T08:30:00Z", want: time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)},
+		{value: "2024-01-31", want: salesforce.Date(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))},
+		{value: "2024-02-30", want: "2024-02-30"},
+		{value: "'2024-01-31'", want: "2024-01-31"},
+		{value: "TODAY", want: salesforce.DateLiteral("TODAY")},
+		{value: "last_n_days:30", want: salesforce.DateLiteral("LAST_N_DAYS:30")},
+		{value: "LAST_N_DAYS:", want: "LAST_N_DAYS:"},
+		{value: "TODAY OR Id != null", want: "TODAY OR Id != null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := filterValue(tt.value)
+			if gotTime, ok := got.(time.Time); ok {
+				if wantTime, ok := tt.want.(time.Time); !ok || !gotTime.Equal(wantTime) {
+					t.Errorf("filterValue(%q) = %#v, want %#v", tt.value, got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("filterValue(%q) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantSOQL   string
+		wantFields []string
+		wantErr    bool
+	}{
+		{
+			name:       "defaults to Id",
+			query:      url.Values{"sobject": {"Product2"}},
+			wantSOQL:   "SELECT Id FROM Product2",
+			wantFields: []string{"Id"},
+		},
+		{
+			name: "every clause",
+			query: url.Values{
+				"sobject": {"Order"}, "fields": {"Id,Account.Name"},
+				"filter": {"Status:ne:Draft", "EffectiveDate:gte:2024-01-01", "CreatedDate:eq:LAST_N_DAYS:30"},
+				"order":  {"-CreatedDate"}, "limit": {"10"},
+			},
+			wantSOQL:   "SELECT Id, Account.Name FROM Order WHERE Status != 'Draft' AND EffectiveDate >= 2024-01-01 AND CreatedDate = LAST_N_DAYS:30 ORDER BY CreatedDate DESC LIMIT 10",
+			wantFields: []string{"Id", "Account.Name", "Status", "EffectiveDate", "CreatedDate", "CreatedDate"},
+		},
+		{
+			name:       "in list",
+			query:      url.Values{"sobject": {"Product2"}, "filter": {"StockKeepingUnit:in:A-1|'42'|B' OR Name != '"}},
+			wantSOQL:   `SELECT Id FROM Product2 WHERE StockKeepingUnit IN ('A-1', '42', 'B\' OR Name != \'')`,
+			wantFields: []string{"Id", "StockKeepingUnit"},
+		},
+		{name: "no sobject", query: url.Values{"fields": {"Id"}}, wantErr: true},
+		{name: "malformed filter", query: url.Values{"sobject": {"Product2"}, "filter": {"Name"}}, wantErr: true},
+		{name: "unknown operator", query: url.Values{"sobject": {"Product2"}, "filter": {"Name:or:x"}}, wantErr: true},
+		{name: "injected field", query: url.Values{"sobject": {"Product2"}, "filter": {"Name = 'x' OR Id:eq:1"}}, wantErr: true},
+		{name: "bad limit", query: url.Values{"sobject": {"Product2"}, "limit": {"-1"}}, wantErr: true},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/query?"+tt.query.Encode(), nil)
+
+			soql, _, fields, err := buildQuery(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("buildQuery() = %s, want an error", soql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildQuery() error: %v", err)
+			}
+			if soql != tt.wantSOQL {
+				t.Errorf("buildQuery() =\n%s\nwant\n%s", soql, tt.wantSOQL)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
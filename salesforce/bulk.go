@@ -0,0 +1,159 @@
+package salesforce
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Bulk API 2.0 job states
+const (
+	JobStateOpen           = "Open"
+	JobStateUploadComplete = "UploadComplete"
+	JobStateInProgress     = "InProgress"
+	JobStateJobComplete    = "JobComplete"
+	JobStateFailed         = "Failed"
+	JobStateAborted        = "Aborted"
+)
+
+// Result sets of a finished ingest job
+const (
+	IngestResultsSuccessful  = "successfulResults"
+	IngestResultsFailed      = "failedResults"
+	IngestResultsUnprocessed = "unprocessedrecords"
+)
+
+// Line endings an ingest job's CSV can use
+const (
+	LineEndingLF   = "LF"
+	LineEndingCRLF = "CRLF"
+)
+
+// Job is the part of a Bulk API 2.0 job's info the connector uses.
+type Job struct {
+	ID                     string `json:"id"`
+	Object                 string `json:"object"`
+	Operation              string `json:"operation"`
+	State                  string `json:"state"`
+	ExternalIDFieldName    string `json:"externalIdFieldName,omitempty"`
+	NumberRecordsProcessed int    `json:"numberRecordsProcessed"`
+	NumberRecordsFailed    int    `json:"numberRecordsFailed"`
+	ErrorMessage           string `json:"errorMessage,omitempty"`
+}
+
+// Finished reports whether the job has reached a state it won't leave.
+func (j *Job) Finished() bool {
+	return j.State == JobStateJobComplete || j.State == JobStateFailed || j.State == JobStateAborted
+}
+
+// CreateIngestJob opens a CSV ingest job. operation is insert, update,
+// upsert, delete or hardDelete; upserts need externalIDField. lineEnding is
+// LineEndingLF or LineEndingCRLF and must match the data uploaded.
+func (c *Client) CreateIngestJob(ctx context.Context, sobjectType, operation, externalIDField, lineEnding string) (*Job, error) {
+	request := map[string]string{
+		"object":      sobjectType,
+		"operation":   operation,
+		"contentType": "CSV",
+		"lineEnding":  lineEnding,
+	}
+	if externalIDField != "" {
+		request["externalIdFieldName"] = externalIDField
+	}
+	var job Job
+	if err := c.Do(ctx, http.MethodPost, "/jobs/ingest", request, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UploadJobData sends the job's CSV. A job takes a single upload.
+func (c *Client) UploadJobData(ctx context.Context, jobID string, csv []byte) error {
+	response, err := c.Send(ctx, http.MethodPut, "/jobs/ingest/"+url.PathEscape(jobID)+"/batches", "text/csv", csv)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// SetIngestJobState moves a job to UploadComplete, so Salesforce starts
+// processing it, or to Aborted.
+func (c *Client) SetIngestJobState(ctx context.Context, jobID, state string) (*Job, error) {
+	var job Job
+	if err := c.Do(ctx, http.MethodPatch, "/jobs/ingest/"+url.PathEscape(jobID), map[string]string{"state": state}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *Client) GetIngestJob(ctx context.Context, jobID string) (*Job, error) {
+	var job Job
+	if err := c.Do(ctx, http.MethodGet, "/jobs/ingest/"+url.PathEscape(jobID), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// IngestJobResults streams one of a finished job's result sets as CSV; the
+// caller closes it.
+func (c *Client) IngestJobResults(ctx context.Context, jobID, resultSet string) (io.ReadCloser, error) {
+	response, err := c.send(ctx, http.MethodGet, "/jobs/ingest/"+url.PathEscape(jobID)+"/"+resultSet, "", "text/csv", nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// CreateQueryJob starts a Bulk API 2.0 query job whose results come back as CSV.
+func (c *Client) CreateQueryJob(ctx context.Context, soql string) (*Job, error) {
+	request := map[string]string{
+		"operation":   "query",
+		"query":       soql,
+		"contentType": "CSV",
+		"lineEnding":  "LF",
+	}
+	var job Job
+	if err := c.Do(ctx, http.MethodPost, "/jobs/query", request, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *Client) GetQueryJob(ctx context.Context, jobID string) (*Job, error) {
+	var job Job
+	if err := c.Do(ctx, http.MethodGet, "/jobs/query/"+url.PathEscape(jobID), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *Client) AbortQueryJob(ctx context.Context, jobID string) error {
+	return c.Do(ctx, http.MethodPatch, "/jobs/query/"+url.PathEscape(jobID), map[string]string{"state": JobStateAborted}, nil)
+}
+
+// QueryJobResults streams one page of a completed query job's CSV, starting
+// at locator ("" for the first page). Every page repeats the header row. The
+// returned locator is "" after the last page; the caller closes the reader.
+func (c *Client) QueryJobResults(ctx context.Context, jobID, locator string, maxRecords int) (io.ReadCloser, string, error) {
+	params := url.Values{}
+	if locator != "" {
+		params.Set("locator", locator)
+	}
+	if maxRecords > 0 {
+		params.Set("maxRecords", strconv.Itoa(maxRecords))
+	}
+	path := "/jobs/query/" + url.PathEscape(jobID) + "/results"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	response, err := c.send(ctx, http.MethodGet, path, "", "text/csv", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	next := response.Header.Get("Sforce-Locator")
+	if next == "null" {
+		next = ""
+	}
+	return response.Body, next, nil
+}
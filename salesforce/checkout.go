@@ -0,0 +1,113 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ActiveCheckout stands in for the ID of the account's current checkout.
+const ActiveCheckout = "active"
+
+// Checkout is a checkout as Connect Commerce returns it. Ready is false while
+// pricing, promotions, tax or shipping are still being calculated, which
+// Salesforce signals by answering 202.
+type Checkout struct {
+	CommerceResponse
+	Ready bool
+}
+
+// ID returns the checkout's ID, or "" when Salesforce hasn't assigned one yet.
+func (co *Checkout) ID() string {
+	id, _ := co.CommerceResponse["checkoutId"].(string)
+	return id
+}
+
+// Errors returns the calculation errors Salesforce reported on the checkout,
+// e.g. an address it can't ship to or a failed tax calculation.
+func (co *Checkout) Errors() []interface{} {
+	errs, _ := co.CommerceResponse["errors"].([]interface{})
+	return errs
+}
+
+// DeliveryGroups returns the checkout's delivery groups, each with its
+// address, availableDeliveryMethods and selectedDeliveryMethod.
+func (co *Checkout) DeliveryGroups() []map[string]interface{} {
+	groups := co.CommerceResponse["deliveryGroups"]
+	// a collection object in most versions, a plain list in some
+	if collection, ok := groups.(map[string]interface{}); ok {
+		groups = collection["items"]
+	}
+	list, _ := groups.([]interface{})
+	var result []map[string]interface{}
+	for _, item := range list {
+		if group, ok := item.(map[string]interface{}); ok {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// HasDeliveryMethod reports whether any delivery group offers the method.
+func (co *Checkout) HasDeliveryMethod(deliveryMethodID string) bool {
+	for _, group := range co.DeliveryGroups() {
+		methods, _ := group["availableDeliveryMethods"].([]interface{})
+		for _, m := range methods {
+			if method, ok := m.(map[string]interface{}); ok && method["id"] == deliveryMethodID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetCheckout reads a checkout's current state. checkoutID may be ActiveCheckout.
+func (c *Client) GetCheckout(ctx context.Context, webstoreID, checkoutID, accountID string) (*Checkout, error) {
+	response, err := c.Send(ctx, http.MethodGet, withAccount(webstorePath(webstoreID, "checkouts", checkoutID), accountID), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	checkout := &Checkout{Ready: response.StatusCode != http.StatusAccepted}
+	if err := json.NewDecoder(response.Body).Decode(&checkout.CommerceResponse); err != nil {
+		return nil, err
+	}
+	return checkout, nil
+}
+
+// UpdateCheckout changes a checkout, e.g. its deliveryAddress or
+// deliveryMethodId. Salesforce recalculates it afterwards, so wait for it
+// before reading totals.
+func (c *Client) UpdateCheckout(ctx context.Context, webstoreID, checkoutID, accountID string, changes map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPatch, withAccount(webstorePath(webstoreID, "checkouts", checkoutID), accountID), changes)
+}
+
+// DeleteCheckout cancels a checkout, leaving its cart to be checked out again.
+func (c *Client) DeleteCheckout(ctx context.Context, webstoreID, checkoutID, accountID string) error {
+	return c.Do(ctx, http.MethodDelete, withAccount(webstorePath(webstoreID, "checkouts", checkoutID), accountID), nil, nil)
+}
+
+// WaitForCheckout polls a checkout every interval until its calculations are
+// done or ctx ends. On timeout the last state is returned with ctx's error.
+func (c *Client) WaitForCheckout(ctx context.Context, webstoreID, checkoutID, accountID string, interval time.Duration) (*Checkout, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last *Checkout
+	for {
+		checkout, err := c.GetCheckout(ctx, webstoreID, checkoutID, accountID)
+		if err != nil && ctx.Err() != nil {
+			return last, ctx.Err()
+		}
+		if err != nil || checkout.Ready {
+			return checkout, err
+		}
+		last = checkout
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package salesforce is a small client for the Salesforce REST and Connect
+// Commerce APIs. A Client is bound to one org and gets its access tokens from
+// a TokenSource, so callers never deal with OAuth directly.
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHTTPClient is used by clients that don't set their own. Sharing it
+// keeps connections to the org alive across requests. It bounds how long
+// Salesforce may take to answer rather than the whole exchange, so large
+// Bulk API uploads and downloads aren't cut off; use the request context
+// for an overall deadline.
+var DefaultHTTPClient = &http.Client{Transport: defaultTransport()}
+
+func defaultTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}
+
+// Client calls the REST API of a single org.
+type Client struct {
+	// BaseURL is the org's My Domain origin, e.g. https://example.my.salesforce.com
+	BaseURL string
+	// APIVersion is the data API version used for relative paths, e.g. v58.0
+	APIVersion string
+	Tokens     TokenSource
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+}
+
+func NewClient(baseURL, apiVersion string, tokens TokenSource) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIVersion: apiVersion,
+		Tokens:     tokens,
+		HTTPClient: DefaultHTTPClient,
+		Retry:      DefaultRetryPolicy,
+	}
+}
+
+// WithAPIVersion returns a copy of the client that targets another API version.
+func (c *Client) WithAPIVersion(apiVersion string) *Client {
+	clone := *c
+	clone.APIVersion = apiVersion
+	return &clone
+}
+
+// URL resolves path against /services/data/<APIVersion>. Paths that already
+// start with /services/ (such as nextRecordsUrl) are only prefixed with the org.
+func (c *Client) URL(path string) string {
+	if strings.HasPrefix(path, "/services/") {
+		return c.BaseURL + path
+	}
+	return c.BaseURL + "/services/data/" + c.APIVersion + path
+}
+
+// Do sends body (if any) as JSON and decodes a JSON response into out (if
+// non-nil). Responses outside 2xx are returned as *APIError.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	contentType := ""
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+
+	response, err := c.Send(ctx, method, path, contentType, payload)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// Send performs an authorized request and returns the raw response for the
+// caller to read and close. Non-2xx responses are consumed and returned as
+// *APIError.
+//
+// Transport errors and transient answers (5xx, REQUEST_LIMIT_EXCEEDED) are
+// retried for idempotent methods according to c.Retry. A 401
+// INVALID_SESSION_ID drops the token and is retried once with a fresh one
+// whatever the method, since Salesforce didn't process the request.
+func (c *Client) Send(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, error) {
+	return c.send(ctx, method, path, contentType, "application/json", body)
+}
+
+// send is Send for endpoints that answer with something other than JSON.
+func (c *Client) send(ctx context.Context, method, path, contentType, accept string, body []byte) (*http.Response, error) {
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		accessToken, err := c.Tokens.Token(ctx)
+		if err != nil {
+			return nil, &TokenError{Err: err}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.URL(path), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Accept", accept)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		response, err := c.HTTPClient.Do(req)
+		if err != nil {
+			if ctx.Err() == nil && isIdempotent(method) && attempt < c.Retry.MaxAttempts {
+				if err := c.Retry.wait(ctx, attempt, 0); err == nil {
+					continue
+				}
+			}
+			return nil, err
+		}
+		if response.StatusCode >= 200 && response.StatusCode < 300 {
+			return response, nil
+		}
+
+		data, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		apiErr := newAPIError(response.StatusCode, data)
+
+		if response.StatusCode == http.StatusUnauthorized {
+			c.Tokens.Invalidate(accessToken)
+			if !refreshed && (len(apiErr.Errors) == 0 || apiErr.HasErrorCode("INVALID_SESSION_ID")) {
+				refreshed = true
+				attempt--
+				continue
+			}
+			return nil, apiErr
+		}
+
+		if apiErr.transient() && isIdempotent(method) && attempt < c.Retry.MaxAttempts {
+			if err := c.Retry.wait(ctx, attempt, retryAfter(response.Header)); err == nil {
+				continue
+			}
+		}
+		return nil, apiErr
+	}
+}
+
+// Error is one entry of the error list Salesforce sends with a failed call.
+type Error struct {
+	ErrorCode string   `json:"errorCode"`
+	Message   string   `json:"message"`
+	Fields    []string `json:"fields,omitempty"`
+}
+
+// APIError is a non-2xx answer from Salesforce. Errors holds the parsed
+// errorCode/message/fields entries when the body had any.
+type APIError struct {
+	StatusCode int
+	Errors     []Error
+	Body       []byte
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: body}
+	// REST and Connect APIs answer with a list of errors, a few endpoints with a single one
+	if json.Unmarshal(body, &e.Errors) != nil {
+		var single Error
+		if json.Unmarshal(body, &single) == nil && (single.ErrorCode != "" || single.Message != "") {
+			e.Errors = []Error{single}
+		}
+	}
+	return e
+}
+
+// HasErrorCode reports whether Salesforce returned code among its errors.
+func (e *APIError) HasErrorCode(code string) bool {
+	for _, err := range e.Errors {
+		if err.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("salesforce: HTTP %d: %s: %s", e.StatusCode, e.Errors[0].ErrorCode, e.Errors[0].Message)
+	}
+	return fmt.Sprintf("salesforce: HTTP %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+// TokenError means no access token could be obtained for the request.
+type TokenError struct {
+	Err error
+}
+
+func (e *TokenError) Error() string {
+	return "salesforce: getting access token: " + e.Err.Error()
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// Version is one entry of the org's /services/data/ listing.
+type Version struct {
+	Label   string `json:"label"`
+	URL     string `json:"url"`
+	Version string `json:"version"`
+}
+
+// SupportedVersions lists the API versions the org at baseURL serves. The
+// listing is public, so no token is needed.
+func SupportedVersions(ctx context.Context, httpClient *http.Client, baseURL string) ([]Version, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/services/data/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newAPIError(response.StatusCode, data)
+	}
+	var versions []Version
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
@@ -0,0 +1,50 @@
+package salesforce
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxCollectionSize is the most records one sObject Collections call takes.
+const MaxCollectionSize = 200
+
+// collection tags every record with its type, as /composite/sobjects requires.
+func collection(sobjectType string, allOrNone bool, records []map[string]interface{}) map[string]interface{} {
+	typed := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		r := make(map[string]interface{}, len(record)+1)
+		for k, v := range record {
+			r[k] = v
+		}
+		r["attributes"] = map[string]string{"type": sobjectType}
+		typed[i] = r
+	}
+	return map[string]interface{}{"allOrNone": allOrNone, "records": typed}
+}
+
+// CreateRecords inserts up to MaxCollectionSize records of one type. Results
+// come back in the order of records; with allOrNone unset, some may fail
+// while the rest are saved.
+func (c *Client) CreateRecords(ctx context.Context, sobjectType string, allOrNone bool, records []map[string]interface{}) ([]SaveResult, error) {
+	var results []SaveResult
+	err := c.Do(ctx, http.MethodPost, "/composite/sobjects", collection(sobjectType, allOrNone, records), &results)
+	return results, err
+}
+
+// UpdateRecords patches up to MaxCollectionSize records; each needs an Id.
+func (c *Client) UpdateRecords(ctx context.Context, sobjectType string, allOrNone bool, records []map[string]interface{}) ([]SaveResult, error) {
+	var results []SaveResult
+	err := c.Do(ctx, http.MethodPatch, "/composite/sobjects", collection(sobjectType, allOrNone, records), &results)
+	return results, err
+}
+
+// DeleteRecords deletes up to MaxCollectionSize records by ID.
+func (c *Client) DeleteRecords(ctx context.Context, allOrNone bool, ids []string) ([]SaveResult, error) {
+	path := "/composite/sobjects?ids=" + url.QueryEscape(strings.Join(ids, ",")) + "&allOrNone=" + strconv.FormatBool(allOrNone)
+	var results []SaveResult
+	err := c.Do(ctx, http.MethodDelete, path, nil, &results)
+	return results, err
+}
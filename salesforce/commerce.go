@@ -0,0 +1,168 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Connect Commerce responses are large and vary by org configuration, so they
+// are returned as plain JSON objects.
+type CommerceResponse map[string]interface{}
+
+func webstorePath(webstoreID string, parts ...string) string {
+	path := "/commerce/webstores/" + url.PathEscape(webstoreID)
+	for _, part := range parts {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// withAccount makes the call on behalf of a buyer account. Without one it is
+// made as the context user, e.g. the store's guest user.
+func withAccount(path, accountID string) string {
+	if accountID == "" {
+		return path
+	}
+	return path + "?effectiveAccountId=" + url.QueryEscape(accountID)
+}
+
+// withAccountParams is withAccount for calls that take extra options, such as paging.
+func withAccountParams(path, accountID string, params url.Values) string {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if accountID != "" {
+		query.Set("effectiveAccountId", accountID)
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+func (c *Client) commerce(ctx context.Context, method, path string, body interface{}) (CommerceResponse, error) {
+	var result CommerceResponse
+	err := c.Do(ctx, method, path, body, &result)
+	return result, err
+}
+
+// CreateCompositeProduct creates a product together with its category
+// assignment, prices and media in one call to the management API.
+func (c *Client) CreateCompositeProduct(ctx context.Context, webstoreID string, product map[string]interface{}) (CommerceResponse, error) {
+	path := "/commerce/management/webstore/" + url.PathEscape(webstoreID) + "/composite-products"
+	return c.commerce(ctx, http.MethodPost, path, product)
+}
+
+// GetProducts returns the webstore's view of the given products.
+func (c *Client) GetProducts(ctx context.Context, webstoreID, ids string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, webstorePath(webstoreID, "products")+"?ids="+url.QueryEscape(ids), nil)
+}
+
+// CreateCart creates a cart; the body carries the effective account and
+// currency. A buyer has only one active cart per store, and when there is one
+// already Salesforce answers 200 with it instead of 201; created tells the
+// two apart.
+func (c *Client) CreateCart(ctx context.Context, webstoreID string, cart map[string]interface{}) (result CommerceResponse, created bool, err error) {
+	payload, err := json.Marshal(cart)
+	if err != nil {
+		return nil, false, err
+	}
+	response, err := c.Send(ctx, http.MethodPost, webstorePath(webstoreID, "carts"), "application/json", payload)
+	if err != nil {
+		return nil, false, err
+	}
+	defer response.Body.Close()
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, false, err
+	}
+	return result, response.StatusCode == http.StatusCreated, nil
+}
+
+// AddCartItem adds a product to a cart.
+func (c *Client) AddCartItem(ctx context.Context, webstoreID, cartID, accountID string, item map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-items"), accountID), item)
+}
+
+// GetCart returns a cart's summary: totals, item count and status. cartID
+// may also be "active" or "current".
+func (c *Client) GetCart(ctx context.Context, webstoreID, cartID, accountID string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccount(webstorePath(webstoreID, "carts", cartID), accountID), nil)
+}
+
+// GetCartItems lists a page of a cart's items. params may carry pageSize,
+// pageParam and sort.
+func (c *Client) GetCartItems(ctx context.Context, webstoreID, cartID, accountID string, params url.Values) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccountParams(webstorePath(webstoreID, "carts", cartID, "cart-items"), accountID, params), nil)
+}
+
+// UpdateCartItem changes a cart item, typically its quantity.
+func (c *Client) UpdateCartItem(ctx context.Context, webstoreID, cartID, itemID, accountID string, item map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPatch, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-items", itemID), accountID), item)
+}
+
+// DeleteCartItem removes an item from a cart.
+func (c *Client) DeleteCartItem(ctx context.Context, webstoreID, cartID, itemID, accountID string) error {
+	return c.Do(ctx, http.MethodDelete, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-items", itemID), accountID), nil, nil)
+}
+
+// DeleteCart deletes a cart, e.g. one the shopper abandoned.
+func (c *Client) DeleteCart(ctx context.Context, webstoreID, cartID, accountID string) error {
+	return c.Do(ctx, http.MethodDelete, withAccount(webstorePath(webstoreID, "carts", cartID), accountID), nil, nil)
+}
+
+// ApplyCoupon applies a coupon code to a cart; the promotions engine then
+// recalculates the cart's price adjustments.
+func (c *Client) ApplyCoupon(ctx context.Context, webstoreID, cartID, accountID, couponCode string) (CommerceResponse, error) {
+	coupon := map[string]string{"couponCode": couponCode}
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-coupons"), accountID), coupon)
+}
+
+// GetCartCoupons lists the coupons applied to a cart.
+func (c *Client) GetCartCoupons(ctx context.Context, webstoreID, cartID, accountID string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-coupons"), accountID), nil)
+}
+
+// DeleteCartCoupon removes an applied coupon from a cart.
+func (c *Client) DeleteCartCoupon(ctx context.Context, webstoreID, cartID, couponID, accountID string) error {
+	return c.Do(ctx, http.MethodDelete, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-coupons", couponID), accountID), nil, nil)
+}
+
+// GetCartPromotions lists the promotions evaluated on a cart.
+func (c *Client) GetCartPromotions(ctx context.Context, webstoreID, cartID, accountID string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccount(webstorePath(webstoreID, "carts", cartID, "promotions"), accountID), nil)
+}
+
+// GetCartItemPriceAdjustments lists the price adjustments promotions made to
+// one cart item.
+func (c *Client) GetCartItemPriceAdjustments(ctx context.Context, webstoreID, cartID, itemID, accountID string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccount(webstorePath(webstoreID, "carts", cartID, "cart-items", itemID, "price-adjustments"), accountID), nil)
+}
+
+// CreateDeliveryGroup adds a delivery group (shipping address) to a cart.
+func (c *Client) CreateDeliveryGroup(ctx context.Context, webstoreID, cartID, accountID string, group map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "carts", cartID, "delivery-groups"), accountID), group)
+}
+
+// CreateCheckout starts a checkout for the account's active cart.
+func (c *Client) CreateCheckout(ctx context.Context, webstoreID, accountID string, checkout map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "checkouts"), accountID), checkout)
+}
+
+// CreatePayment sets the payment method on a checkout.
+func (c *Client) CreatePayment(ctx context.Context, webstoreID, checkoutID, accountID string, payment map[string]interface{}) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "checkouts", checkoutID, "payments"), accountID), payment)
+}
+
+// CreateOrder places the order for a checkout.
+func (c *Client) CreateOrder(ctx context.Context, webstoreID, checkoutID, accountID string) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodPost, withAccount(webstorePath(webstoreID, "checkouts", checkoutID, "orders"), accountID), nil)
+}
+
+// GetOrderSummaries lists the account's order summaries. params may carry
+// paging options such as pageSize and pageToken.
+func (c *Client) GetOrderSummaries(ctx context.Context, webstoreID, accountID string, params url.Values) (CommerceResponse, error) {
+	return c.commerce(ctx, http.MethodGet, withAccountParams(webstorePath(webstoreID, "order-summaries"), accountID, params), nil)
+}
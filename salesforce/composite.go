@@ -0,0 +1,49 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// MaxCompositeSubrequests is the most subrequests one composite call may carry.
+const MaxCompositeSubrequests = 25
+
+// CompositeSubrequest is one step of a composite call. URL is a full
+// /services/data/... path (see Client.DataPath) and may refer to earlier
+// results as @{referenceId.field}.
+type CompositeSubrequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	ReferenceID string      `json:"referenceId"`
+	Body        interface{} `json:"body,omitempty"`
+}
+
+type CompositeSubresponse struct {
+	Body           json.RawMessage   `json:"body"`
+	HTTPHeaders    map[string]string `json:"httpHeaders"`
+	HTTPStatusCode int               `json:"httpStatusCode"`
+	ReferenceID    string            `json:"referenceId"`
+}
+
+// DataPath returns the absolute data API path for path at the client's version.
+func (c *Client) DataPath(path string) string {
+	return "/services/data/" + c.APIVersion + path
+}
+
+// Composite runs dependent subrequests in one round-trip. With allOrNone a
+// failing subrequest rolls back all the others. The call itself succeeds even
+// when subrequests fail; check each HTTPStatusCode.
+func (c *Client) Composite(ctx context.Context, allOrNone bool, subrequests []CompositeSubrequest) ([]CompositeSubresponse, error) {
+	request := map[string]interface{}{
+		"allOrNone":        allOrNone,
+		"compositeRequest": subrequests,
+	}
+	var response struct {
+		CompositeResponse []CompositeSubresponse `json:"compositeResponse"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/composite", request, &response); err != nil {
+		return nil, err
+	}
+	return response.CompositeResponse, nil
+}
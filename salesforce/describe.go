@@ -0,0 +1,271 @@
+package salesforce
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Describe is the part of an object's describe metadata the connector uses.
+type Describe struct {
+	Name       string          `json:"name"`
+	Label      string          `json:"label"`
+	KeyPrefix  string          `json:"keyPrefix"`
+	Createable bool            `json:"createable"`
+	Updateable bool            `json:"updateable"`
+	Deletable  bool            `json:"deletable"`
+	Fields     []DescribeField `json:"fields"`
+}
+
+type DescribeField struct {
+	Name               string          `json:"name"`
+	Label              string          `json:"label"`
+	Type               string          `json:"type"`
+	Length             int             `json:"length"`
+	Nillable           bool            `json:"nillable"`
+	Createable         bool            `json:"createable"`
+	Updateable         bool            `json:"updateable"`
+	DefaultedOnCreate  bool            `json:"defaultedOnCreate"`
+	ExternalID         bool            `json:"externalId"`
+	RestrictedPicklist bool            `json:"restrictedPicklist"`
+	PicklistValues     []PicklistValue `json:"picklistValues"`
+	ReferenceTo        []string        `json:"referenceTo"`
+	RelationshipName   string          `json:"relationshipName"`
+}
+
+type PicklistValue struct {
+	Value  string `json:"value"`
+	Label  string `json:"label"`
+	Active bool   `json:"active"`
+}
+
+// Describe fetches sobjectType's metadata, including every field.
+func (c *Client) Describe(ctx context.Context, sobjectType string) (*Describe, error) {
+	var describe Describe
+	if err := c.Do(ctx, http.MethodGet, sobjectPath(sobjectType, "describe"), nil, &describe); err != nil {
+		return nil, err
+	}
+	return &describe, nil
+}
+
+// Field returns the field called name, compared case-insensitively like
+// Salesforce does, or nil.
+func (d *Describe) Field(name string) *DescribeField {
+	for i := range d.Fields {
+		if strings.EqualFold(d.Fields[i].Name, name) {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Relationship returns the lookup field whose relationship is called name,
+// e.g. OwnerId for Owner, or nil.
+func (d *Describe) Relationship(name string) *DescribeField {
+	for i := range d.Fields {
+		if d.Fields[i].RelationshipName != "" && strings.EqualFold(d.Fields[i].RelationshipName, name) {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
+
+// WriteOp says which kind of write a record is validated for.
+type WriteOp int
+
+const (
+	OpCreate WriteOp = iota
+	OpUpdate
+	// OpUpsert records may create or update, so fields need only be writable
+	// one way and required fields aren't enforced
+	OpUpsert
+)
+
+var (
+	datePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	timePattern     = regexp.MustCompile(`^\d{2}:\d{2}(:\d{2}(\.\d{1,3})?)?Z?$`)
+	dateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05-0700"}
+)
+
+// Validate checks record against the metadata before it is sent, returning
+// every problem found rather than the first: unknown or read-only fields,
+// values of the wrong type, overlong text, values outside a restricted
+// picklist and, on create, missing required fields. The error codes are
+// the ones Salesforce itself would answer with where it has one.
+func (d *Describe) Validate(record map[string]interface{}, op WriteOp) []Error {
+	var problems []Error
+	present := map[string]bool{}
+	for _, name := range slices.Sorted(maps.Keys(record)) {
+		value := record[name]
+		if name == "attributes" {
+			continue
+		}
+		field := d.Field(name)
+		if field == nil {
+			// A lookup can be set through its relationship and an external ID, e.g. "Account": {"ERP_Number__c": "A1"}
+			if rel := d.Relationship(name); rel != nil {
+				if _, ok := value.(map[string]interface{}); ok {
+					present[strings.ToLower(rel.Name)] = true
+					continue
+				}
+			}
+			problems = append(problems, Error{
+				ErrorCode: "INVALID_FIELD",
+				Message:   fmt.Sprintf("No such field %s on %s", name, d.Name),
+				Fields:    []string{name},
+			})
+			continue
+		}
+		present[strings.ToLower(field.Name)] = value != nil
+
+		writable := field.Createable
+		switch op {
+		case OpUpdate:
+			writable = field.Updateable
+		case OpUpsert:
+			writable = field.Createable || field.Updateable
+		}
+		if !writable {
+			problems = append(problems, Error{
+				ErrorCode: "INVALID_FIELD_FOR_INSERT_UPDATE",
+				Message:   fmt.Sprintf("%s can't be written", field.Name),
+				Fields:    []string{field.Name},
+			})
+			continue
+		}
+		if value == nil {
+			if !field.Nillable && field.Type != "boolean" {
+				problems = append(problems, Error{
+					ErrorCode: "REQUIRED_FIELD_MISSING",
+					Message:   fmt.Sprintf("%s can't be null", field.Name),
+					Fields:    []string{field.Name},
+				})
+			}
+			continue
+		}
+		if code, problem := field.check(value); problem != "" {
+			problems = append(problems, Error{
+				ErrorCode: code,
+				Message:   fmt.Sprintf("%s: %s", field.Name, problem),
+				Fields:    []string{field.Name},
+			})
+		}
+	}
+
+	if op == OpCreate {
+		for _, field := range d.Fields {
+			if field.Required() && !present[strings.ToLower(field.Name)] {
+				problems = append(problems, Error{
+					ErrorCode: "REQUIRED_FIELD_MISSING",
+					Message:   fmt.Sprintf("%s is required", field.Name),
+					Fields:    []string{field.Name},
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// Required reports whether a create must set the field. Checkboxes are never
+// null, they default to false.
+func (f *DescribeField) Required() bool {
+	return f.Createable && !f.Nillable && !f.DefaultedOnCreate && f.Type != "boolean"
+}
+
+// restricted reports whether only the field's picklist values are accepted.
+func (f *DescribeField) restricted() bool {
+	return (f.Type == "picklist" || f.Type == "multipicklist") && f.RestrictedPicklist
+}
+
+// check returns the error code and what is wrong with a non-null value for
+// the field, or "" for both. Numbers and checkboxes may also come as strings,
+// which Salesforce accepts.
+func (f *DescribeField) check(value interface{}) (code, problem string) {
+	switch f.Type {
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return "", ""
+		case string:
+			if _, err := strconv.ParseBool(v); err == nil {
+				return "", ""
+			}
+		}
+		return "INVALID_TYPE", "expected true or false"
+	case "int", "double", "currency", "percent", "long":
+		n, ok := value.(float64)
+		if s, isString := value.(string); isString {
+			var err error
+			n, err = strconv.ParseFloat(s, 64)
+			ok = err == nil
+		}
+		if !ok {
+			return "INVALID_TYPE", "expected a number"
+		}
+		if (f.Type == "int" || f.Type == "long") && n != float64(int64(n)) {
+			return "INVALID_TYPE", "expected a whole number"
+		}
+		return "", ""
+	case "date", "datetime", "time":
+		s, ok := value.(string)
+		if !ok || !validTemporal(f.Type, s) {
+			return "INVALID_TYPE", "expected a " + f.Type + " in ISO 8601 format"
+		}
+		return "", ""
+	case "address", "location":
+		return "INVALID_TYPE", "compound fields are set through their component fields"
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "INVALID_TYPE", "expected text"
+	}
+	if f.restricted() {
+		values := []string{s}
+		if f.Type == "multipicklist" {
+			values = strings.Split(s, ";")
+		}
+		for _, v := range values {
+			if !f.allowsPicklistValue(v) {
+				return "INVALID_OR_NULL_FOR_RESTRICTED_PICKLIST", fmt.Sprintf("%q is not an active picklist value", v)
+			}
+		}
+		return "", ""
+	}
+	if f.Length > 0 && utf8.RuneCountInString(s) > f.Length {
+		return "STRING_TOO_LONG", fmt.Sprintf("longer than %d characters", f.Length)
+	}
+	return "", ""
+}
+
+func (f *DescribeField) allowsPicklistValue(value string) bool {
+	return slices.ContainsFunc(f.PicklistValues, func(p PicklistValue) bool {
+		return p.Active && p.Value == value
+	})
+}
+
+func validTemporal(fieldType, s string) bool {
+	switch fieldType {
+	case "date":
+		if !datePattern.MatchString(s) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "time":
+		return timePattern.MatchString(s)
+	}
+	for _, layout := range dateTimeLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
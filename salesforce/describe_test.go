@@ -0,0 +1,89 @@
+package salesforce
+
+import (
+	"slices"
+	"testing"
+)
+
+var productDescribe = &Describe{
+	Name: "Product2",
+	Fields: []DescribeField{
+		{Name: "Id", Type: "id", Length: 18},
+		{Name: "Name", Type: "string", Length: 10, Createable: true, Updateable: true},
+		{Name: "IsActive", Type: "boolean", Createable: true, Updateable: true, DefaultedOnCreate: true},
+		{Name: "Weight__c", Type: "double", Nillable: true, Createable: true, Updateable: true},
+		{Name: "Stock__c", Type: "int", Nillable: true, Createable: true, Updateable: true},
+		{Name: "LaunchDate__c", Type: "date", Nillable: true, Createable: true, Updateable: true},
+		{Name: "LaunchedAt__c", Type: "datetime", Nillable: true, Createable: true, Updateable: true},
+		{Name: "ERP_Code__c", Type: "string", Length: 20, Nillable: true, Createable: true, ExternalID: true},
+		{
+			Name: "Family", Type: "picklist", Nillable: true, Createable: true, Updateable: true, RestrictedPicklist: true,
+			PicklistValues: []PicklistValue{{Value: "Shoes", Active: true}, {Value: "Hats", Active: false}},
+		},
+		{
+			Name: "Sizes__c", Type: "multipicklist", Nillable: true, Createable: true, Updateable: true, RestrictedPicklist: true,
+			PicklistValues: []PicklistValue{{Value: "S", Active: true}, {Value: "M", Active: true}},
+		},
+		{Name: "Vendor__c", Type: "reference", Length: 18, Nillable: true, Createable: true, Updateable: true, ReferenceTo: []string{"Account"}, RelationshipName: "Vendor__r"},
+		{Name: "CreatedDate", Type: "datetime"},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    map[string]interface{}
+		op        WriteOp
+		wantCodes []string
+	}{
+		{
+			name: "valid create",
+			record: map[string]interface{}{
+				"Name": "Trail Shoe", "IsActive": "true", "Weight__c": "1.5", "Stock__c": float64(3),
+				"LaunchDate__c": "2024-02-29", "LaunchedAt__c": "2024-02-29T10:00:00Z", "Family": "Shoes",
+				"Sizes__c": "S;M", "attributes": map[string]interface{}{"type": "Product2"},
+			},
+			op: OpCreate,
+		},
+		{name: "missing required on create", record: map[string]interface{}{"Family": "Shoes"}, op: OpCreate, wantCodes: []string{"REQUIRED_FIELD_MISSING"}},
+		{name: "required not enforced on update", record: map[string]interface{}{"Family": "Shoes"}, op: OpUpdate},
+		{name: "required not enforced on upsert", record: map[string]interface{}{"Family": "Shoes"}, op: OpUpsert},
+		{name: "field names ignore case", record: map[string]interface{}{"name": "Trail Shoe"}, op: OpCreate},
+		{name: "unknown field", record: map[string]interface{}{"Name": "x", "Colour__c": "red"}, op: OpCreate, wantCodes: []string{"INVALID_FIELD"}},
+		{name: "read-only field", record: map[string]interface{}{"Name": "x", "CreatedDate": "2024-01-01T00:00:00Z"}, op: OpCreate, wantCodes: []string{"INVALID_FIELD_FOR_INSERT_UPDATE"}},
+		{name: "create-only field on update", record: map[string]interface{}{"ERP_Code__c": "P-1"}, op: OpUpdate, wantCodes: []string{"INVALID_FIELD_FOR_INSERT_UPDATE"}},
+		{name: "create-only field on upsert", record: map[string]interface{}{"ERP_Code__c": "P-1"}, op: OpUpsert},
+		{name: "null required field", record: map[string]interface{}{"Name": nil}, op: OpUpdate, wantCodes: []string{"REQUIRED_FIELD_MISSING"}},
+		{name: "null checkbox", record: map[string]interface{}{"IsActive": nil}, op: OpUpdate},
+		{name: "null nillable field", record: map[string]interface{}{"Weight__c": nil}, op: OpUpdate},
+		{name: "not a boolean", record: map[string]interface{}{"IsActive": "yes"}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "not a number", record: map[string]interface{}{"Weight__c": "heavy"}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "fraction for int", record: map[string]interface{}{"Stock__c": 1.5}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "invalid date", record: map[string]interface{}{"LaunchDate__c": "2023-02-29"}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "date for datetime", record: map[string]interface{}{"LaunchedAt__c": "2024-02-29"}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "number for text", record: map[string]interface{}{"Name": float64(7)}, op: OpUpdate, wantCodes: []string{"INVALID_TYPE"}},
+		{name: "too long", record: map[string]interface{}{"Name": "Trail Running Shoe"}, op: OpUpdate, wantCodes: []string{"STRING_TOO_LONG"}},
+		{name: "length counts characters", record: map[string]interface{}{"Name": "Crème brûl"}, op: OpUpdate},
+		{name: "inactive picklist value", record: map[string]interface{}{"Family": "Hats"}, op: OpUpdate, wantCodes: []string{"INVALID_OR_NULL_FOR_RESTRICTED_PICKLIST"}},
+		{name: "unknown multipicklist value", record: map[string]interface{}{"Sizes__c": "S;XL"}, op: OpUpdate, wantCodes: []string{"INVALID_OR_NULL_FOR_RESTRICTED_PICKLIST"}},
+		{name: "lookup by external ID", record: map[string]interface{}{"Vendor__r": map[string]interface{}{"ERP_Number__c": "A1"}}, op: OpUpdate},
+		{name: "relationship needs an object", record: map[string]interface{}{"Vendor__r": "A1"}, op: OpUpdate, wantCodes: []string{"INVALID_FIELD"}},
+		{
+			name:      "every problem reported",
+			record:    map[string]interface{}{"Colour__c": "red", "Stock__c": "many", "Family": "Hats"},
+			op:        OpCreate,
+			wantCodes: []string{"INVALID_FIELD", "INVALID_OR_NULL_FOR_RESTRICTED_PICKLIST", "INVALID_TYPE", "REQUIRED_FIELD_MISSING"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codes []string
+			for _, problem := range productDescribe.Validate(tt.record, tt.op) {
+				codes = append(codes, problem.ErrorCode)
+			}
+			if !slices.Equal(codes, tt.wantCodes) {
+				t.Errorf("Validate() codes = %v, want %v", codes, tt.wantCodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package salesforce
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// QueryResult is one page of SOQL results. When Done is false,
+// NextRecordsURL can be passed to QueryMore for the next page.
+type QueryResult struct {
+	TotalSize      int       `json:"totalSize"`
+	Done           bool      `json:"done"`
+	NextRecordsURL string    `json:"nextRecordsUrl,omitempty"`
+	Records        []SObject `json:"records"`
+}
+
+// Query runs a SOQL statement and returns the first page of results.
+func (c *Client) Query(ctx context.Context, soql string) (*QueryResult, error) {
+	var result QueryResult
+	if err := c.Do(ctx, http.MethodGet, "/query?q="+url.QueryEscape(soql), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// QueryMore fetches the page at a previous result's NextRecordsURL.
+func (c *Client) QueryMore(ctx context.Context, nextRecordsURL string) (*QueryResult, error) {
+	var result QueryResult
+	if err := c.Do(ctx, http.MethodGet, nextRecordsURL, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
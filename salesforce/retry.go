@@ -0,0 +1,109 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient failures are retried. Delays grow
+// exponentially from BaseDelay and are jittered so parallel callers don't
+// retry in lockstep.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try; 1 disables retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay caps a single wait. A Retry-After longer than this isn't
+	// honored and the error is returned instead.
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+var errRetryAfterTooLong = errors.New("salesforce: Retry-After exceeds the retry policy's MaxDelay")
+
+// wait sleeps before retry number attempt, or returns early with an error if
+// ctx ends or the server asked for a longer pause than the policy allows.
+func (p RetryPolicy) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	delay := retryAfter
+	if delay > p.MaxDelay {
+		return errRetryAfterTooLong
+	}
+	if delay == 0 {
+		delay = p.backoff(attempt)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff is "full jitter": a random delay up to BaseDelay*2^(attempt-1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// retryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(h http.Header) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// isIdempotent reports whether a request can be repeated without side
+// effects piling up. PATCH is included because every PATCH the REST API
+// takes sets fields to absolute values.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// transient reports whether the same request may succeed if tried again.
+func (e *APIError) transient() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return e.HasErrorCode("REQUEST_LIMIT_EXCEEDED") || e.HasErrorCode("SERVER_UNAVAILABLE")
+}
+
+// temporaryError marks a token endpoint failure worth retrying.
+type temporaryError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *temporaryError) Error() string { return e.err.Error() }
+func (e *temporaryError) Unwrap() error { return e.err }
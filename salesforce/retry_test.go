@@ -0,0 +1,211 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rotatingToken hands out token0, then token1 once that is invalidated, and so on.
+type rotatingToken struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (r *rotatingToken) Token(context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return "token" + strconv.Itoa(r.n), nil
+}
+
+func (r *rotatingToken) Invalidate(accessToken string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if accessToken == "token"+strconv.Itoa(r.n) {
+		r.n++
+	}
+}
+
+type scriptedResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+func TestSendRetries(t *testing.T) {
+	ok := scriptedResponse{status: http.StatusOK, body: `{}`}
+	unavailable := scriptedResponse{status: http.StatusServiceUnavailable}
+	expired := scriptedResponse{status: http.StatusUnauthorized, body: `[{"errorCode":"INVALID_SESSION_ID","message":"Session expired or invalid"}]`}
+	tests := []struct {
+		name       string
+		method     string
+		responses  []scriptedResponse
+		wantStatus int // 0 when the call should succeed
+		wantAuth   []string
+		minElapsed time.Duration
+	}{
+		{
+			name:      "GET retried on 503",
+			method:    http.MethodGet,
+			responses: []scriptedResponse{unavailable, unavailable, ok},
+			wantAuth:  []string{"token0", "token0", "token0"},
+		},
+		{
+			name:       "GET gives up after MaxAttempts",
+			method:     http.MethodGet,
+			responses:  []scriptedResponse{unavailable, unavailable, unavailable, ok},
+			wantStatus: http.StatusServiceUnavailable,
+			wantAuth:   []string{"token0", "token0", "token0"},
+		},
+		{
+			name:       "Retry-After honored",
+			method:     http.MethodGet,
+			responses:  []scriptedResponse{{status: http.StatusTooManyRequests, retryAfter: "1"}, ok},
+			wantAuth:   []string{"token0", "token0"},
+			minElapsed: time.Second,
+		},
+		{
+			name:       "Retry-After beyond MaxDelay",
+			method:     http.MethodGet,
+			responses:  []scriptedResponse{{status: http.StatusTooManyRequests, retryAfter: "60"}, ok},
+			wantStatus: http.StatusTooManyRequests,
+			wantAuth:   []string{"token0"},
+		},
+		{
+			name:      "request limit error code",
+			method:    http.MethodPatch,
+			responses: []scriptedResponse{{status: http.StatusForbidden, body: `[{"errorCode":"REQUEST_LIMIT_EXCEEDED","message":"TotalRequests Limit exceeded."}]`}, ok},
+			wantAuth:  []string{"token0", "token0"},
+		},
+		{
+			name:       "POST not retried",
+			method:     http.MethodPost,
+			responses:  []scriptedResponse{unavailable, ok},
+			wantStatus: http.StatusServiceUnavailable,
+			wantAuth:   []string{"token0"},
+		},
+		{
+			name:       "client error not retried",
+			method:     http.MethodGet,
+			responses:  []scriptedResponse{{status: http.StatusBadRequest, body: `[{"errorCode":"MALFORMED_QUERY","message":"unexpected token"}]`}, ok},
+			wantStatus: http.StatusBadRequest,
+			wantAuth:   []string{"token0"},
+		},
+		{
+			name:      "expired session refreshed",
+			method:    http.MethodGet,
+			responses: []scriptedResponse{expired, ok},
+			wantAuth:  []string{"token0", "token1"},
+		},
+		{
+			// Salesforce didn't process the request, so even a POST is sent again
+			name:      "expired session refreshed for POST",
+			method:    http.MethodPost,
+			responses: []scriptedResponse{expired, ok},
+			wantAuth:  []string{"token0", "token1"},
+		},
+		{
+			name:       "refreshed only once",
+			method:     http.MethodGet,
+			responses:  []scriptedResponse{expired, expired, ok},
+			wantStatus: http.StatusUnauthorized,
+			wantAuth:   []string{"token0", "token1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auth []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response := tt.responses[min(len(auth), len(tt.responses)-1)]
+				auth = append(auth, r.Header.Get("Authorization")[len("Bearer "):])
+				if response.retryAfter != "" {
+					w.Header().Set("Retry-After", response.retryAfter)
+				}
+				w.WriteHeader(response.status)
+				w.Write([]byte(response.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "v58.0", &rotatingToken{})
+			client.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+			start := time.Now()
+			err := client.Do(context.Background(), tt.method, "/sobjects/Product2/01t", nil, nil)
+
+			if tt.wantStatus == 0 && err != nil {
+				t.Fatalf("Do() error: %v", err)
+			}
+			if tt.wantStatus != 0 {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Do() error = %v, want HTTP %d", err, tt.wantStatus)
+				}
+			}
+			if len(auth) != len(tt.wantAuth) {
+				t.Fatalf("sent with tokens %v, want %v", auth, tt.wantAuth)
+			}
+			for i := range auth {
+				if auth[i] != tt.wantAuth[i] {
+					t.Errorf("request %d sent with %s, want %s", i, auth[i], tt.wantAuth[i])
+				}
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt     int
+		wantCeiling time.Duration
+	}{
+		{attempt: 1, wantCeiling: 100 * time.Millisecond},
+		{attempt: 2, wantCeiling: 200 * time.Millisecond},
+		{attempt: 4, wantCeiling: 800 * time.Millisecond},
+		{attempt: 5, wantCeiling: time.Second},
+		{attempt: 40, wantCeiling: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := policy.backoff(tt.attempt); d <= 0 || d > tt.wantCeiling {
+					t.Fatalf("backoff(%d) = %v, want within (0, %v]", tt.attempt, d, tt.wantCeiling)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{name: "missing", header: "", min: 0, max: 0},
+		{name: "seconds", header: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "http date", header: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "date in the past", header: "Mon, 02 Jan 2006 15:04:05 GMT", min: 0, max: 0},
+		{name: "negative", header: "-5", min: 0, max: 0},
+		{name: "garbage", header: "soon", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("Retry-After", tt.header)
+			}
+			if got := retryAfter(h); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter(%q) = %v, want between %v and %v", tt.header, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SObject is a record as Salesforce returns it, keyed by field API name.
+type SObject map[string]interface{}
+
+// SaveResult is what Salesforce answers to a create.
+type SaveResult struct {
+	ID      string  `json:"id"`
+	Success bool    `json:"success"`
+	Errors  []Error `json:"errors"`
+}
+
+func sobjectPath(sobjectType string, id ...string) string {
+	path := "/sobjects/" + url.PathEscape(sobjectType)
+	for _, part := range id {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// GetSObject reads one record by ID, limited to fields when any are given.
+func (c *Client) GetSObject(ctx context.Context, sobjectType, id string, fields ...string) (SObject, error) {
+	path := sobjectPath(sobjectType, id)
+	if len(fields) > 0 {
+		path += "?fields=" + url.QueryEscape(strings.Join(fields, ","))
+	}
+	var record SObject
+	err := c.Do(ctx, http.MethodGet, path, nil, &record)
+	return record, err
+}
+
+// CreateSObject inserts a record built from fields.
+func (c *Client) CreateSObject(ctx context.Context, sobjectType string, fields map[string]interface{}) (*SaveResult, error) {
+	var result SaveResult
+	if err := c.Do(ctx, http.MethodPost, sobjectPath(sobjectType), fields, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateSObject patches the given fields on a record.
+func (c *Client) UpdateSObject(ctx context.Context, sobjectType, id string, fields map[string]interface{}) error {
+	return c.Do(ctx, http.MethodPatch, sobjectPath(sobjectType, id), fields, nil)
+}
+
+// DeleteSObject deletes a record by ID.
+func (c *Client) DeleteSObject(ctx context.Context, sobjectType, id string) error {
+	return c.Do(ctx, http.MethodDelete, sobjectPath(sobjectType, id), nil, nil)
+}
+
+// UpsertResult is what Salesforce answers to an upsert. Created tells an
+// insert from an update.
+type UpsertResult struct {
+	ID      string  `json:"id"`
+	Success bool    `json:"success"`
+	Created bool    `json:"created"`
+	Errors  []Error `json:"errors"`
+}
+
+// UpsertSObject creates or updates the record whose externalIDField equals
+// value. Versions before 46.0 answer an update with 204 and no body, in which
+// case only Created and Success are set.
+func (c *Client) UpsertSObject(ctx context.Context, sobjectType, externalIDField, value string, fields map[string]interface{}) (*UpsertResult, error) {
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.Send(ctx, http.MethodPatch, sobjectPath(sobjectType, externalIDField, value), "application/json", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	result := UpsertResult{Success: true, Created: response.StatusCode == http.StatusCreated}
+	if response.StatusCode == http.StatusNoContent {
+		return &result, nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// KeyPrefix returns the three-character ID prefix of sobjectType's records.
+func (c *Client) KeyPrefix(ctx context.Context, sobjectType string) (string, error) {
+	var info struct {
+		ObjectDescribe struct {
+			KeyPrefix string `json:"keyPrefix"`
+		} `json:"objectDescribe"`
+	}
+	err := c.Do(ctx, http.MethodGet, sobjectPath(sobjectType), nil, &info)
+	return info.ObjectDescribe.KeyPrefix, err
+}
@@ -0,0 +1,212 @@
+package salesforce
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SOQL builds a SELECT statement. Field and object names are checked against
+// the API name syntax and values are rendered as escaped literals, so input
+// from callers can never change the shape of the query.
+//
+//	q, err := Select("Id", "Name").From("Product2").Where("Name", "=", name).Build()
+type SOQL struct {
+	fields     []string
+	from       string
+	conditions []string
+	orderBy    []string
+	limit      int
+	err        error
+}
+
+// identifierPattern matches API names, including relationship paths such as Account.Owner.Name.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)*$`)
+
+var soqlOperators = map[string]bool{
+	"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "IN": true, "NOT IN": true, "INCLUDES": true, "EXCLUDES": true,
+}
+
+func Select(fields ...string) *SOQL {
+	q := &SOQL{}
+	for _, f := range fields {
+		q.fields = append(q.fields, q.identifier(f))
+	}
+	return q
+}
+
+func (q *SOQL) From(sobjectType string) *SOQL {
+	q.from = q.identifier(sobjectType)
+	return q
+}
+
+// Where adds a condition; several are joined with AND. value may be a
+// string, bool, integer, float, time.Time, nil, or for IN/NOT IN a slice.
+func (q *SOQL) Where(field, operator string, value interface{}) *SOQL {
+	operator = strings.ToUpper(strings.TrimSpace(operator))
+	if !soqlOperators[operator] {
+		q.setErr(fmt.Errorf("soql: unsupported operator %q", operator))
+		return q
+	}
+	literal, err := Literal(value)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	multi := operator == "IN" || operator == "NOT IN" || operator == "INCLUDES" || operator == "EXCLUDES"
+	if multi != strings.HasPrefix(literal, "(") {
+		q.setErr(fmt.Errorf("soql: %s needs a list value", operator))
+		return q
+	}
+	q.conditions = append(q.conditions, q.identifier(field)+" "+operator+" "+literal)
+	return q
+}
+
+func (q *SOQL) OrderBy(field string, descending bool) *SOQL {
+	clause := q.identifier(field)
+	if descending {
+		clause += " DESC"
+	} else {
+		clause += " ASC"
+	}
+	q.orderBy = append(q.orderBy, clause)
+	return q
+}
+
+func (q *SOQL) Limit(n int) *SOQL {
+	if n < 0 {
+		q.setErr(fmt.Errorf("soql: negative limit %d", n))
+	}
+	q.limit = n
+	return q
+}
+
+// Build returns the statement, or the first problem found while building it.
+func (q *SOQL) Build() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	if len(q.fields) == 0 || q.from == "" {
+		return "", fmt.Errorf("soql: a query needs fields and an object")
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + strings.Join(q.fields, ", ") + " FROM " + q.from)
+	if len(q.conditions) > 0 {
+		b.WriteString(" WHERE " + strings.Join(q.conditions, " AND "))
+	}
+	if len(q.orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(q.orderBy, ", "))
+	}
+	if q.limit > 0 {
+		b.WriteString(" LIMIT " + strconv.Itoa(q.limit))
+	}
+	return b.String(), nil
+}
+
+// IsValidName reports whether name is a well-formed API name or relationship path.
+func IsValidName(name string) bool {
+	return identifierPattern.MatchString(name)
+}
+
+func (q *SOQL) identifier(name string) string {
+	if !identifierPattern.MatchString(name) {
+		q.setErr(fmt.Errorf("soql: invalid name %q", name))
+	}
+	return name
+}
+
+func (q *SOQL) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// Date is a calendar date for Date fields, which don't compare against
+// datetimes. It renders as YYYY-MM-DD.
+type Date time.Time
+
+// DateLiteral is one of SOQL's relative dates, such as TODAY, LAST_MONTH or
+// LAST_N_DAYS:30. It renders unquoted, so only names matching
+// dateLiteralPattern are accepted.
+type DateLiteral string
+
+var dateLiteralPattern = regexp.MustCompile(`^(YESTERDAY|TODAY|TOMORROW|LAST_90_DAYS|NEXT_90_DAYS|` +
+	`(LAST|THIS|NEXT)_(WEEK|MONTH|QUARTER|YEAR|FISCAL_QUARTER|FISCAL_YEAR)|` +
+	`((LAST|NEXT)_N_(DAYS|WEEKS|MONTHS|QUARTERS|YEARS|FISCAL_QUARTERS|FISCAL_YEARS)|` +
+	`N_(DAYS|WEEKS|MONTHS|QUARTERS|YEARS|FISCAL_QUARTERS|FISCAL_YEARS)_AGO):[0-9]+)$`)
+
+// Valid reports whether d is a date literal SOQL knows. Case matters.
+func (d DateLiteral) Valid() bool {
+	return dateLiteralPattern.MatchString(string(d))
+}
+
+// Literal renders value as a SOQL literal.
+func Literal(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return QuoteString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05Z"), nil
+	case Date:
+		return time.Time(v).Format("2006-01-02"), nil
+	case DateLiteral:
+		if !v.Valid() {
+			return "", fmt.Errorf("soql: invalid date literal %q", string(v))
+		}
+		return string(v), nil
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return Literal(items)
+	case []interface{}:
+		if len(v) == 0 {
+			return "", fmt.Errorf("soql: empty list")
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return "", fmt.Errorf("soql: nested list")
+			}
+			lit, err := Literal(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = lit
+		}
+		return "(" + strings.Join(items, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("soql: unsupported value type %T", value)
+}
+
+var soqlEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\b", `\b`,
+	"\f", `\f`,
+)
+
+// QuoteString returns s as a quoted SOQL string literal. Non-ASCII text is
+// left as is; the query is sent UTF-8 and URL encoded by Client.Query.
+func QuoteString(s string) string {
+	return "'" + soqlEscaper.Replace(s) + "'"
+}
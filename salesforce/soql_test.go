@@ -0,0 +1,133 @@
+package salesforce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuoteString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Shirts", `'Shirts'`},
+		{"single quote", "Men's Shirts", `'Men\'s Shirts'`},
+		{"injection", "x' OR Name != '", `'x\' OR Name != \''`},
+		{"double quote", `12" Pizza`, `'12\" Pizza'`},
+		{"backslash", `C:\temp`, `'C:\\temp'`},
+		{"backslash before quote", `\'`, `'\\\''`},
+		{"control characters", "a\nb\tc\rd", `'a\nb\tc\rd'`},
+		{"unicode", "Café ☕ 日本", `'Café ☕ 日本'`},
+		{"empty", "", `''`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteString(tt.in); got != tt.want {
+				t.Errorf("QuoteString(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSOQLBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *SOQL
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "name lookup",
+			query: Select("Id").From("ProductCategory").Where("Name", "=", "Men's"),
+			want:  `SELECT Id FROM ProductCategory WHERE Name = 'Men\'s'`,
+		},
+		{
+			name: "all clauses",
+			query: Select("Id", "Name", "Account.Name").From("Order").
+				Where("Status", "!=", "Draft").Where("TotalAmount", ">=", 10.5).
+				OrderBy("CreatedDate", true).Limit(20),
+			want: `SELECT Id, Name, Account.Name FROM Order WHERE Status != 'Draft' AND TotalAmount >= 10.5 ORDER BY CreatedDate DESC LIMIT 20`,
+		},
+		{
+			name:  "in list",
+			query: Select("Id").From("Product2").Where("StockKeepingUnit", "in", []string{"A'1", "B\\2"}),
+			want:  `SELECT Id FROM Product2 WHERE StockKeepingUnit IN ('A\'1', 'B\\2')`,
+		},
+		{
+			name:  "null and bool",
+			query: Select("Id").From("Product2").Where("Description", "=", nil).Where("IsActive", "=", true),
+			want:  `SELECT Id FROM Product2 WHERE Description = null AND IsActive = true`,
+		},
+		{
+			name:  "unicode",
+			query: Select("Id").From("Product2").Where("Name", "LIKE", "Crème%"),
+			want:  `SELECT Id FROM Product2 WHERE Name LIKE 'Crème%'`,
+		},
+		{
+			name: "dates",
+			query: Select("Id").From("Order").
+				Where("EffectiveDate", ">=", Date(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))).
+				Where("CreatedDate", "=", DateLiteral("LAST_N_DAYS:30")).Where("LastModifiedDate", "<", DateLiteral("TODAY")),
+			want: `SELECT Id FROM Order WHERE EffectiveDate >= 2024-01-01 AND CreatedDate = LAST_N_DAYS:30 AND LastModifiedDate < TODAY`,
+		},
+		{name: "unknown date literal", query: Select("Id").From("Order").Where("CreatedDate", "=", DateLiteral("TODAY OR Id != null")), wantErr: true},
+		{name: "injected field", query: Select("Id FROM User --").From("Product2"), wantErr: true},
+		{name: "injected object", query: Select("Id").From("Product2 WHERE Name != null"), wantErr: true},
+		{name: "unknown operator", query: Select("Id").From("Product2").Where("Name", "= 'x' OR Name", "y"), wantErr: true},
+		{name: "in without list", query: Select("Id").From("Product2").Where("Name", "IN", "x"), wantErr: true},
+		{name: "list without in", query: Select("Id").From("Product2").Where("Name", "=", []string{"x"}), wantErr: true},
+		{name: "no fields", query: Select().From("Product2"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Build()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Build() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Build() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+func (s staticToken) Invalidate(string)                     {}
+
+func TestQueryEncodesSOQL(t *testing.T) {
+	tests := []string{
+		`SELECT Id FROM Product2 WHERE Name = 'Men\'s & Boys'`,
+		`SELECT Id FROM Product2 WHERE Name = 'C:\\temp #1 100%'`,
+		`SELECT Id FROM Product2 WHERE Name = 'Café ☕ + 日本'`,
+	}
+	for _, soql := range tests {
+		t.Run(soql, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query().Get("q")
+				w.Write([]byte(`{"totalSize":0,"done":true,"records":[]}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "v58.0", staticToken("token"))
+			if _, err := client.Query(context.Background(), soql); err != nil {
+				t.Fatal(err)
+			}
+			if got != soql {
+				t.Errorf("server received %q, want %q", got, soql)
+			}
+		})
+	}
+}
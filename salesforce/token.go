@@ -0,0 +1,219 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource hands out access tokens for one org/client.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	// Invalidate tells the source Salesforce rejected accessToken.
+	Invalidate(accessToken string)
+}
+
+// Grant fetches a brand new access token from the org's OAuth endpoint.
+type Grant interface {
+	FetchToken(ctx context.Context, httpClient *http.Client) (string, error)
+}
+
+// ClientCredentialsGrant is the OAuth 2.0 client credentials flow.
+type ClientCredentialsGrant struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+}
+
+func (g ClientCredentialsGrant) FetchToken(ctx context.Context, httpClient *http.Client) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", g.ClientID)
+	form.Set("client_secret", g.ClientSecret)
+	return requestToken(ctx, httpClient, g.TokenURL, form)
+}
+
+// tokenResponse is the token endpoint's answer, success or failure.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	InstanceURL      string `json:"instance_url"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// OAuthError is the token endpoint turning the credentials down, e.g. with
+// invalid_client or invalid_grant. Retrying won't help; the caller has to fix
+// the credentials.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return "token request failed: " + e.Code + ": " + e.Description
+}
+
+// requestToken posts form to the token endpoint. Credentials always go in the
+// body so they never show up in URLs or access logs.
+func requestToken(ctx context.Context, httpClient *http.Client, tokenURL string, form url.Values) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return "", &temporaryError{err: err}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", &temporaryError{err: err}
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		err := fmt.Errorf("token request failed: %s", response.Status)
+		return "", &temporaryError{err: err, retryAfter: retryAfter(response.Header)}
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("token endpoint returned %s: %w", response.Status, err)
+	}
+
+	// Salesforce reports OAuth failures as error/error_description rather than an empty token
+	if token.Error != "" {
+		return "", &OAuthError{Code: token.Error, Description: token.ErrorDescription}
+	}
+	if response.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("token request failed: %s returned no access token", response.Status)
+	}
+	return token.AccessToken, nil
+}
+
+// TokenCache keeps one access token per key (typically org + client) and makes
+// sure a burst of parallel requests for the same key results in a single
+// token fetch. Salesforce doesn't say when its tokens expire, so they are
+// kept for a fixed TTL or until invalidated. Token fetches that fail on the
+// network or with a 5xx are retried according to Retry.
+type TokenCache struct {
+	Retry RetryPolicy
+
+	ttl time.Duration
+
+	mu       sync.Mutex
+	tokens   map[string]cachedToken
+	inflight map[string]*tokenCall
+}
+
+type cachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// tokenCall is a token fetch in progress; waiters block on done.
+type tokenCall struct {
+	done        chan struct{}
+	accessToken string
+	err         error
+}
+
+func NewTokenCache(ttl time.Duration) *TokenCache {
+	return &TokenCache{
+		Retry:    DefaultRetryPolicy,
+		ttl:      ttl,
+		tokens:   make(map[string]cachedToken),
+		inflight: make(map[string]*tokenCall),
+	}
+}
+
+// Source returns a TokenSource for key that fetches tokens with grant.
+func (tc *TokenCache) Source(key string, grant Grant, httpClient *http.Client) TokenSource {
+	return &cachedSource{cache: tc, key: key, grant: grant, httpClient: httpClient}
+}
+
+// get returns the cached token for key, calling fetch when there is none or
+// it has expired. Concurrent callers for the same key share one fetch. A
+// caller whose ctx ends stops waiting for it, while the fetch carries on in
+// the background for the others.
+func (tc *TokenCache) get(ctx context.Context, key string, fetch func() (string, error)) (string, error) {
+	tc.mu.Lock()
+	if t, ok := tc.tokens[key]; ok && time.Now().Before(t.expiresAt) {
+		tc.mu.Unlock()
+		return t.accessToken, nil
+	}
+	call, ok := tc.inflight[key]
+	if !ok {
+		call = &tokenCall{done: make(chan struct{})}
+		tc.inflight[key] = call
+		go tc.fetch(key, call, fetch)
+	}
+	tc.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.accessToken, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// fetch runs a shared token fetch and hands the result to its waiters.
+func (tc *TokenCache) fetch(key string, call *tokenCall, fetch func() (string, error)) {
+	call.accessToken, call.err = fetch()
+
+	tc.mu.Lock()
+	delete(tc.inflight, key)
+	if call.err == nil {
+		tc.tokens[key] = cachedToken{accessToken: call.accessToken, expiresAt: time.Now().Add(tc.ttl)}
+	}
+	tc.mu.Unlock()
+	close(call.done)
+}
+
+// invalidate drops the cached token for key if it is still the one that was
+// rejected, so a token refreshed in the meantime by another request survives.
+func (tc *TokenCache) invalidate(key, accessToken string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if t, ok := tc.tokens[key]; ok && t.accessToken == accessToken {
+		delete(tc.tokens, key)
+	}
+}
+
+type cachedSource struct {
+	cache      *TokenCache
+	key        string
+	grant      Grant
+	httpClient *http.Client
+}
+
+func (s *cachedSource) Token(ctx context.Context) (string, error) {
+	return s.cache.get(ctx, s.key, func() (string, error) {
+		// The fetch is shared with other waiters, so one caller going away mustn't cancel it
+		ctx := context.WithoutCancel(ctx)
+		policy := s.cache.Retry
+		for attempt := 1; ; attempt++ {
+			accessToken, err := s.grant.FetchToken(ctx, s.httpClient)
+			var temporary *temporaryError
+			if err == nil || !errors.As(err, &temporary) || attempt >= policy.MaxAttempts {
+				return accessToken, err
+			}
+			if policy.wait(ctx, attempt, temporary.retryAfter) != nil {
+				return "", err
+			}
+		}
+	})
+}
+
+func (s *cachedSource) Invalidate(accessToken string) {
+	s.cache.invalidate(s.key, accessToken)
+}
@@ -0,0 +1,187 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenCacheGet(t *testing.T) {
+	errDenied := errors.New("denied")
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		fetchErrs   []error
+		calls       int
+		wantFetches int
+		wantToken   string
+		wantErr     bool
+	}{
+		{name: "cached", ttl: time.Hour, calls: 3, wantFetches: 1, wantToken: "token1"},
+		{name: "expired", ttl: -time.Second, calls: 3, wantFetches: 3, wantToken: "token3"},
+		{name: "failure not cached", ttl: time.Hour, fetchErrs: []error{errDenied}, calls: 2, wantFetches: 2, wantToken: "token2"},
+		{name: "failure returned", ttl: time.Hour, fetchErrs: []error{errDenied}, calls: 1, wantFetches: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewTokenCache(tt.ttl)
+			fetches := 0
+			fetch := func() (string, error) {
+				fetches++
+				if fetches <= len(tt.fetchErrs) {
+					return "", tt.fetchErrs[fetches-1]
+				}
+				return "token" + strconv.Itoa(fetches), nil
+			}
+
+			var got string
+			var err error
+			for i := 0; i < tt.calls; i++ {
+				got, err = cache.get(context.Background(), "org", fetch)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("get() = %q, want an error", got)
+				}
+			} else if err != nil {
+				t.Fatalf("get() error: %v", err)
+			}
+			if got != tt.wantToken {
+				t.Errorf("get() = %q, want %q", got, tt.wantToken)
+			}
+			if fetches != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", fetches, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestTokenCacheSingleFlight(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		callers     int
+		wantFetches int32
+	}{
+		{name: "one key", keys: []string{"org"}, callers: 20, wantFetches: 1},
+		{name: "separate keys", keys: []string{"org1", "org2", "org3"}, callers: 10, wantFetches: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewTokenCache(time.Hour)
+			var fetches atomic.Int32
+			release := make(chan struct{})
+
+			var wg sync.WaitGroup
+			for _, key := range tt.keys {
+				for i := 0; i < tt.callers; i++ {
+					wg.Add(1)
+					go func(key string) {
+						defer wg.Done()
+						token, err := cache.get(context.Background(), key, func() (string, error) {
+							fetches.Add(1)
+							<-release
+							return "token-" + key, nil
+						})
+						if err != nil {
+							t.Error(err)
+						}
+						if token != "token-"+key {
+							t.Errorf("get(%s) = %q", key, token)
+						}
+					}(key)
+				}
+			}
+			// let every caller reach the cache before the first fetch returns
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := fetches.Load(); got != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestTokenCacheInvalidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		rejected    string
+		wantToken   string
+		wantFetches int
+	}{
+		{name: "current token", rejected: "token1", wantToken: "token2", wantFetches: 2},
+		// another request already refreshed it; don't throw the new one away
+		{name: "stale token", rejected: "token0", wantToken: "token1", wantFetches: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewTokenCache(time.Hour)
+			fetches := 0
+			fetch := func() (string, error) {
+				fetches++
+				return "token" + strconv.Itoa(fetches), nil
+			}
+
+			if _, err := cache.get(context.Background(), "org", fetch); err != nil {
+				t.Fatal(err)
+			}
+			cache.invalidate("org", tt.rejected)
+			got, err := cache.get(context.Background(), "org", fetch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantToken {
+				t.Errorf("get() = %q, want %q", got, tt.wantToken)
+			}
+			if fetches != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", fetches, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestTokenCacheCanceledCaller(t *testing.T) {
+	tests := []struct {
+		name          string
+		callersBefore int
+	}{
+		{name: "caller that started the fetch", callersBefore: 0},
+		{name: "caller waiting on another's fetch", callersBefore: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewTokenCache(time.Hour)
+			release := make(chan struct{})
+			fetch := func() (string, error) {
+				<-release
+				return "token", nil
+			}
+			for i := 0; i < tt.callersBefore; i++ {
+				go cache.get(context.Background(), "org", fetch)
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if _, err := cache.get(ctx, "org", fetch); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("get() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			// the fetch carries on and its token serves later callers
+			close(release)
+			got, err := cache.get(context.Background(), "org", func() (string, error) {
+				t.Error("token fetched again")
+				return "", nil
+			})
+			if err != nil || got != "token" {
+				t.Errorf("get() = %q, %v, want token", got, err)
+			}
+		})
+	}
+}
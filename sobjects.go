@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sobjectAllowlist says which object types, and which of their fields, a
+// tenant may reach through the generic routes. Keys are lower-cased API
+// names; a nil field list allows every field.
+type sobjectAllowlist map[string][]string
+
+// The objects the connector had dedicated routes for before the generic ones.
+const defaultSObjects = "Product2:*;Order:*;Account:*;Payment:*;ProductCategory:*"
+
+var defaultAllowlist sobjectAllowlist
+
+// parseAllowlist reads "Type:Field,Field;Type:*" as used by CONNECTOR_SOBJECTS
+// and TENANT_<ID>_SOBJECTS.
+func parseAllowlist(spec string) (sobjectAllowlist, error) {
+	list := sobjectAllowlist{}
+	for _, entry := range splitList(spec, ";") {
+		sobjectType, fields, _ := strings.Cut(entry, ":")
+		sobjectType = strings.TrimSpace(sobjectType)
+		if sobjectType == "" {
+			return nil, fmt.Errorf("invalid sObject allowlist entry %q", entry)
+		}
+		fieldList := splitList(fields, ",")
+		if len(fieldList) == 0 || (len(fieldList) == 1 && fieldList[0] == "*") {
+			fieldList = nil
+		}
+		list[strings.ToLower(sobjectType)] = fieldList
+	}
+	return list, nil
+}
+
+// newAllowlist builds an allowlist from the map form used in the tenants file.
+func newAllowlist(sobjects map[string][]string) sobjectAllowlist {
+	list := sobjectAllowlist{}
+	for sobjectType, fields := range sobjects {
+		if len(fields) == 0 || (len(fields) == 1 && fields[0] == "*") {
+			fields = nil
+		}
+		list[strings.ToLower(sobjectType)] = fields
+	}
+	return list
+}
+
+func loadDefaultAllowlist() (sobjectAllowlist, error) {
+	spec := os.Getenv("CONNECTOR_SOBJECTS")
+	if spec == "" {
+		spec = defaultSObjects
+	}
+	return parseAllowlist(spec)
+}
+
+func (l sobjectAllowlist) allows(sobjectType string) bool {
+	_, ok := l[strings.ToLower(sobjectType)]
+	return ok
+}
+
+// fields returns the allowed fields of sobjectType, or nil when all are allowed.
+func (l sobjectAllowlist) fields(sobjectType string) []string {
+	return l[strings.ToLower(sobjectType)]
+}
+
+// readFields returns the fields to fetch when reading a sobjectType record:
+// those requested, or when none are, everything the tenant may see. Tenants
+// limited to certain fields only ever get those back. nil means all fields.
+func (l sobjectAllowlist) readFields(sobjectType string, requested []string) []string {
+	if len(requested) > 0 {
+		return requested
+	}
+	return l.fields(sobjectType)
+}
+
+// deniedFields returns the names in fields the tenant may not touch on
+// sobjectType. Id is always allowed, since records can't be read back,
+// updated or deleted without it. Relationship paths such as Owner.Email are
+// left to deniedPath.
+func (l sobjectAllowlist) deniedFields(sobjectType string, fields []string) []string {
+	allowed := l.fields(sobjectType)
+	if allowed == nil {
+		return nil
+	}
+	var denied []string
+	for _, f := range fields {
+		if !strings.Contains(f, ".") && !strings.EqualFold(f, "Id") && !containsFold(allowed, f) {
+			denied = append(denied, f)
+		}
+	}
+	return denied
+}
+
+// deniedPath reports whether the tenant may not follow the relationship path
+// from sobjectType, e.g. Owner.Email on Order. Each hop's lookup field must be
+// allowed, every object it can point to must be allowed, and the last name
+// must be an allowed field on all of them. The objects on the way are found
+// through their (cached) describes.
+func deniedPath(ctx context.Context, cred credentials, client *salesforce.Client, sobjectType, path string) (bool, error) {
+	hops := strings.Split(path, ".")
+	types := []string{sobjectType}
+	for _, hop := range hops[:len(hops)-1] {
+		var next []string
+		for _, t := range types {
+			describe, err := describes.get(ctx, cred, client, t)
+			if err != nil {
+				return false, err
+			}
+			lookup := describe.Relationship(hop)
+			if lookup == nil || len(lookup.ReferenceTo) == 0 || len(cred.sobjects.deniedFields(t, []string{lookup.Name})) > 0 {
+				return true, nil
+			}
+			for _, target := range lookup.ReferenceTo {
+				if !cred.sobjects.allows(target) {
+					return true, nil
+				}
+				next = append(next, target)
+			}
+		}
+		types = next
+	}
+	for _, t := range types {
+		if len(cred.sobjects.deniedFields(t, hops[len(hops)-1:])) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsFold reports whether list holds name, ignoring case as API names do.
+func containsFold(list []string, name string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSObjectAccess answers 403 and returns false when the tenant may not use
+// sobjectType or any of fields, including the objects relationship paths
+// among fields lead to.
+func checkSObjectAccess(c *gin.Context, sobjectType string, fields []string) bool {
+	allowlist := credential(c).sobjects
+	if !allowlist.allows(sobjectType) {
+		c.JSON(http.StatusForbidden, errorBody(c, http.StatusForbidden, "Object type "+sobjectType+" is not allowed"))
+		return false
+	}
+	denied := allowlist.deniedFields(sobjectType, fields)
+	for _, f := range fields {
+		if !strings.Contains(f, ".") {
+			continue
+		}
+		client := salesforceClient(c, apiVersions.sobjects)
+		deny, err := deniedPath(c.Request.Context(), credential(c), client, sobjectType, f)
+		if err != nil {
+			respondError(c, err, "Failed to describe "+sobjectType)
+			return false
+		}
+		if deny {
+			denied = append(denied, f)
+		}
+	}
+	if len(denied) > 0 {
+		body := errorBody(c, http.StatusForbidden, "Fields not allowed on "+sobjectType)
+		body["fields"] = denied
+		c.JSON(http.StatusForbidden, body)
+		return false
+	}
+	return true
+}
+
+func bodyFields(body map[string]interface{}) []string {
+	fields := make([]string, 0, len(body))
+	for f := range body {
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func getSObject(c *gin.Context) {
+	sobjectType := c.Param("type")
+	fields := splitList(c.Query("fields"), ",")
+	if !checkSObjectAccess(c, sobjectType, fields) {
+		return
+	}
+	fields = credential(c).sobjects.readFields(sobjectType, fields)
+
+	result, err := salesforceClient(c, apiVersions.sobjects).GetSObject(c.Request.Context(), sobjectType, c.Param("id"), fields...)
+	if err != nil {
+		respondError(c, err, "Failed to get "+sobjectType)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func createSObject(c *gin.Context) {
+	sobjectType := c.Param("type")
+	requestBody, ok := bindBody(c)
+	if !ok || !checkSObjectAccess(c, sobjectType, bodyFields(requestBody)) || !validBody(c, sobjectType, requestBody, salesforce.OpCreate) {
+		return
+	}
+
+	result, err := salesforceClient(c, apiVersions.sobjects).CreateSObject(c.Request.Context(), sobjectType, requestBody)
+	if err != nil {
+		respondError(c, err, "Failed to create "+sobjectType)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": sobjectType + " created successfully",
+		"result":  result,
+	})
+}
+
+func updateSObject(c *gin.Context) {
+	sobjectType := c.Param("type")
+	requestBody, ok := bindBody(c)
+	if !ok || !checkSObjectAccess(c, sobjectType, bodyFields(requestBody)) || !validBody(c, sobjectType, requestBody, salesforce.OpUpdate) {
+		return
+	}
+
+	if err := salesforceClient(c, apiVersions.sobjects).UpdateSObject(c.Request.Context(), sobjectType, c.Param("id"), requestBody); err != nil {
+		respondError(c, err, "Failed to update "+sobjectType)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": sobjectType + " updated successfully"})
+}
+
+func deleteSObject(c *gin.Context) {
+	sobjectType := c.Param("type")
+	if !checkSObjectAccess(c, sobjectType, nil) {
+		return
+	}
+
+	if err := salesforceClient(c, apiVersions.sobjects).DeleteSObject(c.Request.Context(), sobjectType, c.Param("id")); err != nil {
+		respondError(c, err, "Failed to delete "+sobjectType)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": sobjectType + " deleted successfully"})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+)
+
+func TestDeniedFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		sobject    string
+		fields     []string
+		wantDenied []string
+	}{
+		{name: "all fields allowed", spec: "Product2:*", sobject: "Product2", fields: []string{"Id", "Name", "Cost__c"}},
+		{name: "listed fields", spec: "Product2:Name,Description", sobject: "product2", fields: []string{"name", "DESCRIPTION"}},
+		{name: "Id always allowed", spec: "Product2:Name,Description", sobject: "Product2", fields: []string{"Id", "Name"}},
+		{name: "Id in any case", spec: "Product2:Name", sobject: "Product2", fields: []string{"ID", "id"}},
+		{name: "unlisted field", spec: "Product2:Name,Description", sobject: "Product2", fields: []string{"Id", "Cost__c", "Name", "Margin__c"}, wantDenied: []string{"Cost__c", "Margin__c"}},
+		{name: "Id-like field", spec: "Product2:Name", sobject: "Product2", fields: []string{"Idx__c"}, wantDenied: []string{"Idx__c"}},
+		{name: "paths left to deniedPath", spec: "Order:Id,Status", sobject: "Order", fields: []string{"Owner.Email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowlist, err := parseAllowlist(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := allowlist.deniedFields(tt.sobject, tt.fields); !slices.Equal(got, tt.wantDenied) {
+				t.Errorf("deniedFields(%s, %v) = %v, want %v", tt.sobject, tt.fields, got, tt.wantDenied)
+			}
+		})
+	}
+}
+
+// staticToken is a TokenSource for tests that never expires.
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+func (s staticToken) Invalidate(string)                     {}
+
+func TestDeniedPath(t *testing.T) {
+	describes := map[string]string{
+		"Order": `{"name":"Order","fields":[{"name":"Id"},{"name":"Status"},
+			{"name":"OwnerId","relationshipName":"Owner","referenceTo":["Group","User"]},
+			{"name":"AccountId","relationshipName":"Account","referenceTo":["Account"]}]}`,
+		"Account": `{"name":"Account","fields":[{"name":"Id"},{"name":"Name"},{"name":"AnnualRevenue"},
+			{"name":"ParentId","relationshipName":"Parent","referenceTo":["Account"]}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for sobjectType, describe := range describes {
+			if strings.HasSuffix(r.URL.Path, "/sobjects/"+sobjectType+"/describe") {
+				w.Write([]byte(describe))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`[{"errorCode":"NOT_FOUND","message":"The requested resource does not exist"}]`))
+	}))
+	defer server.Close()
+	client := salesforce.NewClient(server.URL, "v58.0", staticToken("token"))
+
+	tests := []struct {
+		name       string
+		spec       string
+		path       string
+		wantDenied bool
+	}{
+		{name: "allowed lookup", spec: "Order:*;Account:*", path: "Account.Name"},
+		{name: "relationship name ignores case", spec: "Order:*;Account:*", path: "account.name"},
+		{name: "Id of allowed object", spec: "Order:*;Account:Name", path: "Account.Id"},
+		{name: "two hops", spec: "Order:*;Account:*", path: "Account.Parent.Name"},
+		{name: "object off the allowlist", spec: "Order:*;Account:*", path: "Owner.Email", wantDenied: true},
+		{name: "polymorphic needs every target", spec: "Order:*;Account:*;User:*", path: "Owner.Name", wantDenied: true},
+		{name: "polymorphic with every target", spec: "Order:*;Account:*;User:*;Group:*", path: "Owner.Name"},
+		{name: "field off the target's list", spec: "Order:*;Account:Name", path: "Account.AnnualRevenue", wantDenied: true},
+		{name: "lookup field off the list", spec: "Order:Id,Status;Account:*", path: "Account.Name", wantDenied: true},
+		{name: "lookup field on the list", spec: "Order:Id,AccountId;Account:*", path: "Account.Name"},
+		{name: "second hop field off the list", spec: "Order:*;Account:Name", path: "Account.Parent.Name", wantDenied: true},
+		{name: "unknown relationship", spec: "Order:*;Account:*", path: "Shipment.Name", wantDenied: true},
+		{name: "field name is not a relationship", spec: "Order:*;Account:*", path: "AccountId.Name", wantDenied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowlist, err := parseAllowlist(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cred := credentials{shopURL: server.URL, sobjects: allowlist}
+			denied, err := deniedPath(context.Background(), cred, client, "Order", tt.path)
+			if err != nil {
+				t.Fatalf("deniedPath(%s) error: %v", tt.path, err)
+			}
+			if denied != tt.wantDenied {
+				t.Errorf("deniedPath(%s) = %v, want %v", tt.path, denied, tt.wantDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tenantProfile is a named set of Salesforce credentials kept on the server,
+// so callers only need to send a tenant ID or API key.
+type tenantProfile struct {
+	ID           string `json:"id"`
+	APIKey       string `json:"apiKey"`
+	ShopURL      string `json:"shopUrl"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	WebstoreID   string `json:"webstoreId"`
+
+	// SObjects maps each object type the tenant may use through the generic
+	// routes to its allowed fields (["*"] for all). Empty means the
+	// connector-wide CONNECTOR_SOBJECTS list.
+	SObjects map[string][]string `json:"sobjects"`
+	// ExternalIDFields overrides CONNECTOR_EXTERNAL_ID_FIELDS per object type
+	ExternalIDFields map[string]string `json:"externalIdFields"`
+
+	// AuthFlow is "client_credentials" (default) or "jwt"
+	AuthFlow          string `json:"authFlow"`
+	JWTSubject        string `json:"jwtSubject"`
+	JWTAudience       string `json:"jwtAudience"`
+	JWTPrivateKey     string `json:"jwtPrivateKey"`
+	JWTPrivateKeyFile string `json:"jwtPrivateKeyFile"`
+}
+
+type tenantRegistry struct {
+	byID     map[string]*tenantProfile
+	byAPIKey map[string]*tenantProfile
+
+	// allowHeaderCredentials keeps the old shopUrl/clientId/clientSecret headers working
+	allowHeaderCredentials bool
+}
+
+var tenants = &tenantRegistry{allowHeaderCredentials: true}
+
+var (
+	errUnknownTenant             = errors.New("unknown tenant or API key")
+	errHeaderCredentialsDisabled = errors.New("header-supplied credentials are disabled, send tenantId or apiKey")
+	// The server-side key would sign for whichever user a header named
+	errJWTHeaderCredentials = errors.New("the JWT bearer flow is only available through tenant profiles")
+)
+
+// loadTenants reads tenant profiles from the JSON file named by
+// CONNECTOR_TENANTS_FILE and from TENANT_<ID>_* variables for every ID listed
+// in CONNECTOR_TENANTS. Set CONNECTOR_ALLOW_HEADER_CREDENTIALS=false to only
+// accept profile-based requests.
+func loadTenants() (*tenantRegistry, error) {
+	registry := &tenantRegistry{
+		byID:                   make(map[string]*tenantProfile),
+		byAPIKey:               make(map[string]*tenantProfile),
+		allowHeaderCredentials: os.Getenv("CONNECTOR_ALLOW_HEADER_CREDENTIALS") != "false",
+	}
+
+	var profiles []*tenantProfile
+	if path := os.Getenv("CONNECTOR_TENANTS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var file struct {
+			Tenants []*tenantProfile `json:"tenants"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		profiles = append(profiles, file.Tenants...)
+	}
+	for _, id := range strings.Split(os.Getenv("CONNECTOR_TENANTS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			profile, err := tenantFromEnv(id)
+			if err != nil {
+				return nil, err
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+
+	for _, p := range profiles {
+		if p.ID == "" {
+			return nil, errors.New("tenant profile without an id")
+		}
+		if _, ok := registry.byID[p.ID]; ok {
+			return nil, fmt.Errorf("duplicate tenant profile %q", p.ID)
+		}
+		if err := validateShopURL(p.ShopURL); err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", p.ID, err)
+		}
+		registry.byID[p.ID] = p
+		if p.APIKey != "" {
+			if _, ok := registry.byAPIKey[p.APIKey]; ok {
+				return nil, fmt.Errorf("tenant %q reuses another tenant's API key", p.ID)
+			}
+			registry.byAPIKey[p.APIKey] = p
+		}
+	}
+	return registry, nil
+}
+
+func tenantFromEnv(id string) (*tenantProfile, error) {
+	prefix := "TENANT_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+	var sobjects map[string][]string
+	if spec := os.Getenv(prefix + "SOBJECTS"); spec != "" {
+		allowlist, err := parseAllowlist(spec)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", id, err)
+		}
+		sobjects = allowlist
+	}
+	var externalIDs map[string]string
+	if spec := os.Getenv(prefix + "EXTERNAL_ID_FIELDS"); spec != "" {
+		fields, err := parseExternalIDFields(spec)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", id, err)
+		}
+		externalIDs = fields
+	}
+	return &tenantProfile{
+		ID:                id,
+		APIKey:            os.Getenv(prefix + "API_KEY"),
+		ShopURL:           os.Getenv(prefix + "SHOP_URL"),
+		ClientID:          os.Getenv(prefix + "CLIENT_ID"),
+		ClientSecret:      os.Getenv(prefix + "CLIENT_SECRET"),
+		WebstoreID:        os.Getenv(prefix + "WEBSTORE_ID"),
+		AuthFlow:          os.Getenv(prefix + "AUTH_FLOW"),
+		JWTSubject:        os.Getenv(prefix + "JWT_SUBJECT"),
+		JWTAudience:       os.Getenv(prefix + "JWT_AUDIENCE"),
+		JWTPrivateKey:     os.Getenv(prefix + "JWT_PRIVATE_KEY"),
+		JWTPrivateKeyFile: os.Getenv(prefix + "JWT_PRIVATE_KEY_FILE"),
+		SObjects:          sobjects,
+		ExternalIDFields:  externalIDs,
+	}, nil
+}
+
+// resolve finds the profile for a tenant ID and/or API key. A profile that has
+// an API key configured can't be selected by its tenant ID alone.
+func (r *tenantRegistry) resolve(tenantID, apiKey string) (*tenantProfile, error) {
+	if apiKey != "" {
+		p, ok := r.byAPIKey[apiKey]
+		if !ok || (tenantID != "" && tenantID != p.ID) {
+			return nil, errUnknownTenant
+		}
+		return p, nil
+	}
+	p, ok := r.byID[tenantID]
+	if !ok || p.APIKey != "" {
+		return nil, errUnknownTenant
+	}
+	return p, nil
+}
+
+func (p *tenantProfile) credentials() credentials {
+	cred := credentials{
+		tenantID:     p.ID,
+		shopURL:      strings.TrimSuffix(p.ShopURL, "/"),
+		clientId:     p.ClientID,
+		clientSecret: p.ClientSecret,
+		webstoreId:   p.WebstoreID,
+		sobjects:     defaultAllowlist,
+		grantType:    grantTypeClientCredentials,
+	}
+	if len(p.SObjects) > 0 {
+		cred.sobjects = newAllowlist(p.SObjects)
+	}
+	cred.externalIDs = defaultExternalIDs
+	if len(p.ExternalIDFields) > 0 {
+		cred.externalIDs = externalIDFields{}
+		for sobjectType, field := range defaultExternalIDs {
+			cred.externalIDs[sobjectType] = field
+		}
+		for sobjectType, field := range p.ExternalIDFields {
+			cred.externalIDs[strings.ToLower(sobjectType)] = field
+		}
+	}
+	if p.AuthFlow == "jwt" {
+		cred.grantType = grantTypeJWTBearer
+		cred.jwtSubject = p.JWTSubject
+		cred.jwtAudience = p.JWTAudience
+		cred.jwtPrivateKey = p.JWTPrivateKey
+		cred.jwtPrivateKeyFile = p.JWTPrivateKeyFile
+		applyJWTDefaults(&cred)
+	}
+	return cred
+}
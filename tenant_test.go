@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTenantResolve(t *testing.T) {
+	keyed := &tenantProfile{ID: "acme", APIKey: "acme-key"}
+	open := &tenantProfile{ID: "internal"}
+	other := &tenantProfile{ID: "globex", APIKey: "globex-key"}
+	registry := &tenantRegistry{
+		byID:     map[string]*tenantProfile{"acme": keyed, "internal": open, "globex": other},
+		byAPIKey: map[string]*tenantProfile{"acme-key": keyed, "globex-key": other},
+	}
+	tests := []struct {
+		name     string
+		tenantID string
+		apiKey   string
+		want     *tenantProfile
+	}{
+		{name: "api key", apiKey: "acme-key", want: keyed},
+		{name: "api key and its tenant", tenantID: "acme", apiKey: "acme-key", want: keyed},
+		{name: "tenant without api key", tenantID: "internal", want: open},
+		{name: "api key of another tenant", tenantID: "acme", apiKey: "globex-key"},
+		{name: "tenant id alone when it has a key", tenantID: "acme"},
+		{name: "unknown api key", apiKey: "guess"},
+		{name: "unknown tenant", tenantID: "initech"},
+		{name: "api key used as tenant id", tenantID: "acme-key"},
+		{name: "tenant id used as api key", apiKey: "internal"},
+		{name: "nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.resolve(tt.tenantID, tt.apiKey)
+			if tt.want == nil {
+				if !errors.Is(err, errUnknownTenant) {
+					t.Errorf("resolve(%q, %q) = %v, %v, want errUnknownTenant", tt.tenantID, tt.apiKey, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolve(%q, %q) = %v, %v, want tenant %s", tt.tenantID, tt.apiKey, got, err, tt.want.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Salesforce doesn't return an expiry with its tokens, so we keep them for a
+// fixed window that stays well inside the default session timeout. Override
+// with CONNECTOR_TOKEN_TTL (e.g. "15m").
+const defaultTokenTTL = 30 * time.Minute
+
+var accessTokens *salesforce.TokenCache
+
+// tokenCacheKey identifies the credentials a token was issued for. It covers
+// a digest of the secret so a caller who only knows the org and client ID
+// can't pick up someone else's token, and keeps tenant profiles apart from
+// header credentials.
+func tokenCacheKey(cred credentials) string {
+	source := "header"
+	if cred.tenantID != "" {
+		source = "tenant:" + cred.tenantID
+	}
+	secret := sha256.Sum256([]byte(cred.clientSecret))
+	return source + "|" + cred.shopURL + "|" + cred.clientId + "|" + hex.EncodeToString(secret[:]) + "|" + cred.jwtSubject
+}
+
+// FetchToken makes credentials a salesforce.Grant for the tenant's OAuth flow.
+// The JWT key is loaded here rather than per request since tokens are cached.
+func (cred credentials) FetchToken(ctx context.Context, httpClient *http.Client) (string, error) {
+	if cred.grantType == grantTypeJWTBearer {
+		key, err := jwtPrivateKey(cred)
+		if err != nil {
+			return "", err
+		}
+		grant := salesforce.JWTBearerGrant{
+			TokenURL:   cred.tokenURL,
+			ClientID:   cred.clientId,
+			Subject:    cred.jwtSubject,
+			Audience:   cred.jwtAudience,
+			PrivateKey: key,
+		}
+		return grant.FetchToken(ctx, httpClient)
+	}
+	grant := salesforce.ClientCredentialsGrant{
+		TokenURL:     cred.tokenURL,
+		ClientID:     cred.clientId,
+		ClientSecret: cred.clientSecret,
+	}
+	return grant.FetchToken(ctx, httpClient)
+}
+
+// salesforceClient returns a client for the request's org that shares the
+// tenant's cached access token.
+func salesforceClient(c *gin.Context, apiVersion string) *salesforce.Client {
+	return newSalesforceClient(credential(c), apiVersion)
+}
+
+// newSalesforceClient is salesforceClient for work that outlives the request,
+// such as polling Bulk API jobs.
+func newSalesforceClient(cred credentials, apiVersion string) *salesforce.Client {
+	tokens := accessTokens.Source(tokenCacheKey(cred), cred, salesforce.DefaultHTTPClient)
+	return salesforce.NewClient(cred.shopURL, apiVersion, tokens)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prateek-banga/sfcc-crud/salesforce"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Which field identifies records from outside systems, per object type.
+// Override with CONNECTOR_EXTERNAL_ID_FIELDS, e.g.
+// "Product2:StockKeepingUnit;Account:ERP_Number__c", or per tenant.
+const defaultExternalIDFields = "Product2:StockKeepingUnit"
+
+var defaultExternalIDs externalIDFields
+
+// externalIDFields maps lower-cased object types to their external ID field.
+type externalIDFields map[string]string
+
+func parseExternalIDFields(spec string) (externalIDFields, error) {
+	fields := externalIDFields{}
+	for _, entry := range splitList(spec, ";") {
+		sobjectType, field, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(sobjectType) == "" || strings.TrimSpace(field) == "" {
+			return nil, fmt.Errorf("invalid external ID field entry %q", entry)
+		}
+		fields[strings.ToLower(strings.TrimSpace(sobjectType))] = strings.TrimSpace(field)
+	}
+	return fields, nil
+}
+
+func loadDefaultExternalIDs() (externalIDFields, error) {
+	spec := os.Getenv("CONNECTOR_EXTERNAL_ID_FIELDS")
+	if spec == "" {
+		spec = defaultExternalIDFields
+	}
+	return parseExternalIDFields(spec)
+}
+
+// upsertByExternalID returns a handler that creates or updates a record of
+// sobjectType keyed by the :externalId path parameter.
+func upsertByExternalID(sobjectType, label string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		field := credential(c).externalIDs[strings.ToLower(sobjectType)]
+		if field == "" {
+			c.JSON(http.StatusNotImplemented, errorBody(c, http.StatusNotImplemented, "No external ID field configured for "+sobjectType))
+			return
+		}
+		requestBody, ok := bindBody(c)
+		if !ok || !checkSObjectAccess(c, sobjectType, append(bodyFields(requestBody), field)) || !validBody(c, sobjectType, requestBody, salesforce.OpUpsert) {
+			return
+		}
+
+		result, err := salesforceClient(c, apiVersions.sobjects).UpsertSObject(c.Request.Context(), sobjectType, field, c.Param("externalId"), requestBody)
+		if err != nil {
+			respondError(c, err, "Failed to upsert "+strings.ToLower(label))
+			return
+		}
+
+		if result.Created {
+			c.JSON(http.StatusCreated, gin.H{"message": label + " created", "id": result.ID, "created": true})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": label + " updated", "id": result.ID, "created": false})
+	}
+}